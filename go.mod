@@ -7,12 +7,12 @@ require (
 	github.com/Danny-Dasilva/utls v0.0.0-20220604023528-30cb107b834e
 	github.com/andybalholm/brotli v1.0.4
 	github.com/klauspost/compress v1.15.9
+	golang.org/x/crypto v0.0.0-20220518034528-6f7dac969898
 	golang.org/x/net v0.0.0-20220906165146-f3363e06e74c
 )
 
 require (
 	github.com/dsnet/compress v0.0.1 // indirect
-	golang.org/x/crypto v0.0.0-20220518034528-6f7dac969898 // indirect
 	golang.org/x/sys v0.0.0-20220728004956-3c1f35247d10 // indirect
 	golang.org/x/text v0.3.7 // indirect
 )
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassette(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "visit", Value: "1", Path: "/"})
+		_ = r.ParseForm()
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.Form.Get("name")))
+	}))
+	serverURL := server.URL
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, err := cycletls.NewCassette(path, cycletls.CassetteRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.Matchers = append(recorder.Matchers, cycletls.MatchBody)
+	c := tlsHttpClient.New().SetCassette(recorder).SetHeader("Authorization", "Bearer secret")
+	for _, name := range []string{"first", "second"} {
+		_, err := c.R().SetFormData(map[string]string{"name": name}).Post(serverURL + "/form")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recorder.Err(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") || !strings.Contains(string(data), "REDACTED") {
+		t.Error("Authorization was not redacted:", string(data))
+		return
+	}
+
+	player, err := cycletls.NewCassette(path, cycletls.CassetteReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	player.Matchers = append(player.Matchers, cycletls.MatchBody)
+	c = tlsHttpClient.New().SetCassette(player).SetHeader("Authorization", "Bearer other")
+	for _, name := range []string{"second", "first"} {
+		resp, err := c.R().SetFormData(map[string]string{"name": name}).Post(serverURL + "/form")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if resp.Text != "POST /form "+name || resp.StatusCode != 200 {
+			t.Error("replayed response mismatch; Received:", resp.StatusCode, resp.Text)
+			return
+		}
+		if len(resp.ResponseCookies) != 1 || resp.ResponseCookies[0].Name != "visit" {
+			t.Error("replayed cookies mismatch; Received:", resp.ResponseCookies)
+			return
+		}
+	}
+	cookies, err := c.GetCookies(serverURL)
+	if err != nil || len(cookies) != 1 {
+		t.Error("replayed cookie not in jar; Received:", cookies, err)
+		return
+	}
+
+	if _, err := c.R().Get(serverURL + "/missing"); err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Error("expected unmatched request error; Received:", err)
+	}
+}
@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestContextCancel(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := client.R().ExecuteContext(ctx, "GET", server.URL)
+	if err == nil {
+		t.Error("request was not cancelled")
+	} else if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("unexpected error:", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Error("cancellation took too long:", elapsed)
+	}
+}
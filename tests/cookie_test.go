@@ -1,8 +1,17 @@
 package tests
 
 import (
+	"bytes"
+	fhttp "github.com/Danny-Dasilva/fhttp"
 	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -40,3 +49,234 @@ func TestCookie(t *testing.T) {
 		}
 	}
 }
+
+func TestCookieManagement(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/set" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "1", Path: "/"})
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Cookie")))
+	}))
+	defer server.Close()
+
+	c := tlsHttpClient.New()
+	resp, err := c.R().Get(server.URL + "/set")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(resp.ResponseCookies) != 1 || resp.ResponseCookies[0].Name != "session" || resp.ResponseCookies[0].Path != "/" {
+		t.Error("response cookies mismatch; Received:", resp.ResponseCookies)
+	}
+
+	if err := c.SetCookies(server.URL, tlsHttpClient.Cookie{Name: "manual", Value: "2"}); err != nil {
+		t.Error(err)
+		return
+	}
+	resp, err = c.R().SetCookie(tlsHttpClient.Cookie{Name: "once", Value: "3"}).Get(server.URL + "/echo")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, pair := range []string{"once=3", "session=1", "manual=2"} {
+		if !strings.Contains(resp.Text, pair) {
+			t.Error("cookie not sent; Expected:", pair, "Received:", resp.Text)
+		}
+	}
+	if len(resp.ResponseCookies) != 0 || len(resp.Cookies) != 2 {
+		t.Error("cookie views mismatch; Received:", resp.ResponseCookies, resp.Cookies)
+	}
+
+	if err := c.DeleteCookie(server.URL+"/echo", "session"); err != nil {
+		t.Error(err)
+		return
+	}
+	jar, err := c.GetCookies(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(jar) != 1 || jar[0].Name != "manual" {
+		t.Error("cookie not deleted; Received:", jar)
+	}
+
+	c.ClearCookies()
+	if jar, _ := c.GetCookies(server.URL); len(jar) != 0 {
+		t.Error("cookies not cleared; Received:", jar)
+	}
+
+	rejected := []tlsHttpClient.Cookie{
+		{Name: "suffix", Value: "1", Domain: "co.uk"},
+		{Name: "__Host-id", Value: "1", Domain: "example.com", Path: "/", Secure: true},
+		{Name: "none", Value: "1", Domain: "example.com", SameSite: fhttp.SameSiteNoneMode},
+	}
+	for _, cookie := range rejected {
+		if err := c.SetCookie(cookie); err == nil {
+			t.Error("rejected cookie reported as stored:", cookie.Name)
+		}
+	}
+	if err := c.SetCookie(tlsHttpClient.Cookie{Name: "ok", Value: "1", Domain: "example.com"}); err != nil {
+		t.Error(err)
+	}
+	if err := c.SetCookies("http://example.com/", tlsHttpClient.Cookie{Name: "token", Value: "1", Secure: true}); err == nil {
+		t.Error("Secure cookie from an http url reported as stored")
+	}
+}
+
+func TestCookieJarPersistence(t *testing.T) {
+	jar := cycletls.NewJar()
+	u, _ := url.Parse("https://www.example.com/app/login")
+	jar.SetCookies(u, []*fhttp.Cookie{
+		{Name: "session", Value: "abc", HttpOnly: true},
+		{Name: "pref", Value: "dark", Domain: "example.com", Path: "/", Secure: true, MaxAge: 3600},
+	})
+
+	var netscape strings.Builder
+	if err := jar.WriteNetscape(&netscape); err != nil {
+		t.Error(err)
+		return
+	}
+	for _, line := range []string{
+		"#HttpOnly_www.example.com\tFALSE\t/app\tFALSE\t0\tsession\tabc",
+		".example.com\tTRUE\t/\tTRUE\t",
+	} {
+		if !strings.Contains(netscape.String(), line) {
+			t.Error("cookies.txt line missing; Expected:", line, "Received:", netscape.String())
+		}
+	}
+
+	loaded := cycletls.NewJar()
+	if err := loaded.ReadNetscape(strings.NewReader(netscape.String())); err != nil {
+		t.Error(err)
+		return
+	}
+	var reloaded strings.Builder
+	_ = loaded.WriteNetscape(&reloaded)
+	if reloaded.String() != netscape.String() {
+		t.Error("cookies.txt round trip mismatch; Received:", reloaded.String(), "Expected:", netscape.String())
+	}
+
+	var buf bytes.Buffer
+	if err := jar.WriteJSON(&buf); err != nil {
+		t.Error(err)
+		return
+	}
+	loaded = cycletls.NewJar()
+	if err := loaded.ReadJSON(&buf); err != nil {
+		t.Error(err)
+		return
+	}
+	if got := loaded.Cookies(u); len(got) != 2 {
+		t.Error("JSON round trip mismatch; Received:", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	fileJar, err := cycletls.NewFileJar(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	fileJar.SetCookies(u, []*fhttp.Cookie{{Name: "saved", Value: "1"}})
+	if err := fileJar.Err(); err != nil {
+		t.Error(err)
+		return
+	}
+	reopened, err := cycletls.NewFileJar(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if got := reopened.Cookies(u); len(got) != 1 || got[0].Value != "1" {
+		t.Error("file jar not saved; Received:", got)
+	}
+}
+
+func TestCookieJarRules(t *testing.T) {
+	jar := cycletls.NewJar()
+	secure, _ := url.Parse("https://www.example.co.uk/")
+	insecure, _ := url.Parse("http://www.example.co.uk/")
+	jar.SetCookies(secure, []*fhttp.Cookie{
+		{Name: "suffix", Value: "1", Domain: "co.uk"},
+		{Name: "site", Value: "1", Domain: "example.co.uk"},
+		{Name: "__Host-id", Value: "1", Domain: "example.co.uk", Path: "/", Secure: true},
+		{Name: "__Host-ok", Value: "1", Path: "/", Secure: true},
+		{Name: "none", Value: "1", SameSite: fhttp.SameSiteNoneMode},
+		{Name: "token", Value: "secure", Secure: true},
+		{Name: "legacy", Value: "1", RawExpires: "Sun Nov  6 08:49:37 2094"},
+	})
+	jar.SetCookies(insecure, []*fhttp.Cookie{
+		{Name: "flag", Value: "1", Secure: true},
+		{Name: "token", Value: "plain"},
+	})
+
+	var names []string
+	for _, cookie := range jar.All() {
+		names = append(names, cookie.Name+"="+cookie.Value)
+	}
+	if got := strings.Join(names, ","); got != "site=1,__Host-ok=1,legacy=1,token=secure" {
+		t.Error("jar rules mismatch; Received:", got)
+	}
+	for _, cookie := range jar.All() {
+		if cookie.Name == "legacy" && cookie.Expires.Year() != 2094 {
+			t.Error("legacy expiry not parsed; Received:", cookie.Expires)
+		}
+	}
+}
+
+func TestCookieSameSite(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Cookie")))
+	}))
+	defer server.Close()
+
+	c := tlsHttpClient.New()
+	err := c.SetCookies(server.URL,
+		tlsHttpClient.Cookie{Name: "strict", Value: "1", SameSite: fhttp.SameSiteStrictMode},
+		tlsHttpClient.Cookie{Name: "lax", Value: "1", SameSite: fhttp.SameSiteLaxMode},
+		tlsHttpClient.Cookie{Name: "none", Value: "1", SameSite: fhttp.SameSiteNoneMode, Secure: true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method  string
+		headers map[string]string
+		want    string
+	}{
+		{"GET", nil, "strict=1; lax=1; none=1"},
+		{"GET", map[string]string{"Referer": server.URL + "/page"}, "strict=1; lax=1; none=1"},
+		{"GET", map[string]string{"Referer": "https://other.example/"}, "lax=1; none=1"},
+		{"POST", map[string]string{"Origin": "https://other.example"}, "none=1"},
+		{"GET", map[string]string{"Origin": "https://other.example", "Sec-Fetch-Mode": "cors"}, "none=1"},
+	}
+	for _, test := range tests {
+		resp, err := c.R().SetHeaders(test.headers).Execute(test.method, server.URL)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if resp.Text != test.want {
+			t.Error("SameSite mismatch for", test.method, test.headers, "; Expected:", test.want, "Received:", resp.Text)
+		}
+	}
+}
+
+func TestParseDateString(t *testing.T) {
+	want := time.Date(1994, time.November, 6, 8, 49, 37, 0, time.UTC)
+	for _, value := range []string{
+		"Sun, 06 Nov 1994 08:49:37 GMT",
+		"Sunday, 06-Nov-94 08:49:37 GMT",
+		"Sun Nov  6 08:49:37 1994",
+		"Sun, 06-Nov-1994 08:49:37 GMT",
+		"1994-11-06T08:49:37Z",
+	} {
+		got, err := cycletls.ParseDateString(value)
+		if err != nil || !got.Equal(want) {
+			t.Error("date mismatch for", value, "; Received:", got, err)
+		}
+	}
+	if _, err := cycletls.ParseDateString("Sun, 31 Feb 1994 08:49:37 GMT"); err == nil {
+		t.Error("invalid date accepted")
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+)
+
+type apiError struct {
+	Message string `json:"message"`
+}
+
+type apiItem struct {
+	ID   int    `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+func newDecodeServer() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/items":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{"id":1,"name":"one"},{"id":2,"name":"two"}]`))
+		case "/xml":
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			_, _ = w.Write([]byte(`<apiItem><id>3</id><name>three</name></apiItem>`))
+		default:
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"not found"}`))
+		}
+	}))
+}
+
+func TestDecodeArray(t *testing.T) {
+	server := newDecodeServer()
+	defer server.Close()
+
+	resp, err := client.R().Get(server.URL + "/items")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	items, err := tlsHttpClient.Decode[[]apiItem](resp)
+	if err != nil {
+		t.Error(err)
+	} else if len(items) != 2 || items[1].Name != "two" {
+		t.Error("decoded items mismatch; Received:", items)
+	}
+}
+
+func TestDecodeXML(t *testing.T) {
+	server := newDecodeServer()
+	defer server.Close()
+
+	var item apiItem
+	_, err := client.R().SetResult(&item).Get(server.URL + "/xml")
+	if err != nil {
+		t.Error(err)
+	} else if item.ID != 3 || item.Name != "three" {
+		t.Error("decoded xml mismatch; Received:", item)
+	}
+}
+
+func TestResultAndError(t *testing.T) {
+	server := newDecodeServer()
+	defer server.Close()
+
+	var items []apiItem
+	var apiErr apiError
+	resp, err := client.R().SetResult(&items).SetError(&apiErr).Get(server.URL + "/missing")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if resp.StatusCode != http.StatusNotFound || apiErr.Message != "not found" || items != nil {
+		t.Error("error model mismatch; Received:", apiErr, items)
+	}
+
+	resp, err = client.R().SetResult(&items).SetError(&apiErr).Get(server.URL + "/items")
+	if err != nil {
+		t.Error(err)
+	} else if len(items) != 2 || !strings.Contains(resp.Text, "one") {
+		t.Error("result model mismatch; Received:", items)
+	}
+}
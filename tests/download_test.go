@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+)
+
+func TestDownloadResume(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 1<<14)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "file.bin", time.Unix(0, 0), bytes.NewReader(payload))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+	half := int64(len(payload) / 2)
+	if err := os.WriteFile(path+".part", payload[:half], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".part.info", []byte(`"v1"`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var last tlsHttpClient.DownloadProgress
+	resp, err := client.R().SetDownloadProgress(func(progress tlsHttpClient.DownloadProgress) {
+		if progress.Done < half {
+			t.Error("progress did not include the resumed prefix:", progress.Done)
+		}
+		last = progress
+	}).Download(server.URL, path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if resp.StatusCode != 206 {
+		t.Error("download was not resumed; StatusCode:", resp.StatusCode)
+	}
+	if last.Done != int64(len(payload)) || last.Total != int64(len(payload)) {
+		t.Error("progress mismatch; Done:", last.Done, " Total:", last.Total)
+	}
+
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Error(err)
+	} else if !bytes.Equal(saved, payload) {
+		t.Error("downloaded file mismatch")
+	}
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Error("partial file was not removed")
+	}
+}
+
+func TestDownloadRetry(t *testing.T) {
+	var calls int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		switch {
+		case r.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case n == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = w.Write([]byte("file"))
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+	policy := tlsHttpClient.NewRetryPolicy().SetWaitTime(0, 0)
+	resp, err := client.R().SetAttempts(3).SetRetryPolicy(policy).Download(server.URL, path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if resp.Attempts != 2 {
+		t.Error("503 was not retried; Attempts:", resp.Attempts)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	resp, err = client.R().SetAttempts(3).Download(server.URL+"/missing", path)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusNotFound {
+		t.Error("expected a 404 error; Received:", resp, err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Error("404 was retried; calls:", n)
+	}
+}
+
+func TestDownloadSlowBody(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "5")
+		for i := 0; i < 5; i++ {
+			_, _ = w.Write([]byte("x"))
+			w.(http.Flusher).Flush()
+			if r.URL.Path == "/stall" && i == 2 {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+				return
+			}
+			time.Sleep(300 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	// the body takes longer than the timeout, but data keeps coming
+	path := filepath.Join(t.TempDir(), "file.bin")
+	if _, err := client.R().SetTimeout(1).Download(server.URL, path); err != nil {
+		t.Error(err)
+	} else if saved, _ := os.ReadFile(path); string(saved) != "xxxxx" {
+		t.Error("slow body mismatch; Received:", string(saved))
+	}
+
+	_, err := client.R().SetTimeout(1).Download(server.URL+"/stall", filepath.Join(t.TempDir(), "stall.bin"))
+	if !errors.Is(err, tlsHttpClient.ErrTimeout) {
+		t.Error("expected an idle timeout; Received:", err)
+	}
+}
+
+func TestDownloadMiddlewareResponse(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("live"))
+	}))
+	defer server.Close()
+
+	c := tlsHttpClient.New().OnAfterResponse(func(c *tlsHttpClient.Client, resp *tlsHttpClient.Response) (*tlsHttpClient.Response, error) {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+		return &tlsHttpClient.Response{StatusCode: http.StatusOK, Bytes: []byte("cached")}, nil
+	})
+	path := filepath.Join(t.TempDir(), "file.bin")
+	if _, err := c.R().Download(server.URL, path); err != nil {
+		t.Error(err)
+	} else if saved, _ := os.ReadFile(path); string(saved) != "cached" {
+		t.Error("middleware body mismatch; Received:", string(saved))
+	}
+}
+
+func TestDownloadKeepsRequest(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 1<<10)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "file.bin", time.Unix(0, 0), bytes.NewReader(payload))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+	if err := os.WriteFile(path+".part", payload[:10], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := client.R().SetTimeout(5).SetHeader("Accept-Encoding", "gzip")
+	if _, err := r.Download(server.URL, path); err != nil {
+		t.Error(err)
+		return
+	}
+	if r.Timeout != 5 || r.DoNotParseResponse || r.Headers.Get("Accept-Encoding") != "gzip" ||
+		r.Headers.Get("Range") != "" || r.Headers.Get("If-Range") != "" {
+		t.Error("download changed the request; Timeout:", r.Timeout, " DoNotParseResponse:", r.DoNotParseResponse, " Headers:", r.Headers)
+	}
+
+	resp, err := r.Get(server.URL)
+	if err != nil {
+		t.Error(err)
+	} else if resp.StatusCode != 200 || !bytes.Equal(resp.Bytes, payload) {
+		t.Error("reused request mismatch; StatusCode:", resp.StatusCode, " length:", len(resp.Bytes))
+	}
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls"
+)
+
+func TestErrorDNS(t *testing.T) {
+	_, err := client.R().Get("https://tls-http-client.invalid/")
+	if !errors.Is(err, tlsHttpClient.ErrDNS) {
+		t.Error("expected ErrDNS; Received:", err)
+	}
+}
+
+func TestErrorTimeout(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	_, err := client.R().SetTimeout(1).Get(server.URL)
+	if !errors.Is(err, tlsHttpClient.ErrTimeout) {
+		t.Error("expected ErrTimeout; Received:", err)
+	}
+}
+
+func TestErrorProxyConnect(t *testing.T) {
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusProxyAuthRequired)
+	}))
+	defer proxyServer.Close()
+
+	proxy := tlsHttpClient.StringToProxy(strings.TrimPrefix(proxyServer.URL, "http://"), "http")
+	_, err := client.R().SetProxy(*proxy).Get("https://example.com/")
+
+	var proxyErr *cycletls.ProxyConnectError
+	if !errors.Is(err, tlsHttpClient.ErrProxyConnect) || !errors.As(err, &proxyErr) {
+		t.Error("expected ErrProxyConnect; Received:", err)
+	} else if proxyErr.StatusCode != http.StatusProxyAuthRequired {
+		t.Error("proxy status mismatch; Received:", proxyErr.StatusCode)
+	}
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/tlshttptest"
+	"strings"
+	"testing"
+)
+
+func TestFingerprintServer(t *testing.T) {
+	server := tlshttptest.NewServer()
+	defer server.Close()
+
+	c := tlsHttpClient.New().SetHeaderOrder("x-second", "x-first", "user-agent")
+	resp, err := c.R().
+		SetHeader("X-First", "1").
+		SetHeader("X-Second", "2").
+		Get(server.URL + "/echo?q=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var echoed tlshttptest.Fingerprint
+	if err := json.Unmarshal(resp.Bytes, &echoed); err != nil {
+		t.Fatal(err)
+	}
+	fingerprint, ok := server.Last()
+	if !ok {
+		t.Fatal("no fingerprint recorded")
+	}
+	if echoed.JA3 != fingerprint.JA3 || echoed.JA4 != fingerprint.JA4 || echoed.Path != "/echo?q=1" {
+		t.Error("echoed fingerprint mismatch; Received:", echoed.JA3, echoed.JA4, echoed.Path)
+		return
+	}
+
+	if fingerprint.JA3 != tlsHttpClient.ChromeJA3 {
+		t.Error("JA3 mismatch; Expected:", tlsHttpClient.ChromeJA3, "Received:", fingerprint.JA3)
+		return
+	}
+	if len(fingerprint.JA3Hash) != 32 || !strings.HasPrefix(fingerprint.JA4, "t13d1516h2_8daaf6152771_") {
+		t.Error("JA3 hash or JA4 mismatch; Received:", fingerprint.JA3Hash, fingerprint.JA4)
+		return
+	}
+	if fingerprint.HTTP2 == nil || fingerprint.Proto != "HTTP/2.0" {
+		t.Fatal("expected an HTTP/2 request; Received:", fingerprint.Proto)
+	}
+	if got := strings.Join(fingerprint.HTTP2.PseudoHeaderOrder, ","); got != ":method,:authority,:scheme,:path" {
+		t.Error("pseudo header order mismatch; Received:", got)
+		return
+	}
+	if !strings.HasSuffix(fingerprint.HTTP2.Akamai, "|m,a,s,p") || len(fingerprint.HTTP2.Settings) == 0 {
+		t.Error("akamai fingerprint mismatch; Received:", fingerprint.HTTP2.Akamai)
+		return
+	}
+
+	var order []string
+	for _, name := range fingerprint.HeaderOrder {
+		if name == "x-first" || name == "x-second" || name == "user-agent" {
+			order = append(order, name)
+		}
+	}
+	if strings.Join(order, ",") != "x-second,x-first,user-agent" {
+		t.Error("header order mismatch; Received:", fingerprint.HeaderOrder)
+	}
+}
+
+func TestFingerprintServerHTTP1(t *testing.T) {
+	server := tlshttptest.NewServer()
+	defer server.Close()
+
+	ja3 := strings.Replace(tlsHttpClient.ChromeJA3, "-16-", "-", 1)
+	c := tlsHttpClient.New().SetJA3(ja3).SetHeaderOrder("x-second", "x-first")
+	_, err := c.R().
+		SetHeader("X-First", "1").
+		SetHeader("X-Second", "2").
+		SetBody("payload").
+		Post(server.URL + "/form")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprint, ok := server.Last()
+	if !ok {
+		t.Fatal("no fingerprint recorded")
+	}
+	if fingerprint.JA3 != ja3 || fingerprint.HTTP2 != nil || !strings.HasPrefix(fingerprint.JA4, "t13d151500_") {
+		t.Error("HTTP/1 fingerprint mismatch; Received:", fingerprint.Proto, fingerprint.JA3, fingerprint.JA4)
+		return
+	}
+	if len(fingerprint.HeaderOrder) < 2 || fingerprint.HeaderOrder[0] != "X-Second" || fingerprint.HeaderOrder[1] != "X-First" {
+		t.Error("header order mismatch; Received:", fingerprint.HeaderOrder)
+	}
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHARRecorder(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/start" {
+			http.SetCookie(w, &http.Cookie{Name: "step", Value: "1", Path: "/"})
+			http.Redirect(w, r, "/end?done=1", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte("finished"))
+		_ = gz.Close()
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "traffic.har")
+	recorder := tlsHttpClient.NewHARRecorder(path)
+	c := tlsHttpClient.New().SetHARRecorder(recorder).SetHeaderOrder("x-second", "x-first")
+	_, err := c.R().
+		SetHeader("X-First", "1").
+		SetHeader("X-Second", "2").
+		SetBody("payload").
+		Post(server.URL + "/start")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := recorder.Err(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var har tlsHttpClient.HAR
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Error(err)
+		return
+	}
+	entries := har.Log.Entries
+	if har.Log.Version != "1.2" || len(entries) != 2 {
+		t.Error("HAR log mismatch; Received:", har.Log.Version, len(entries))
+		return
+	}
+
+	first, last := entries[0], entries[1]
+	if first.Response.Status != 302 || first.Response.RedirectURL != "/end?done=1" {
+		t.Error("redirect hop mismatch; Received:", first.Response.Status, first.Response.RedirectURL)
+	}
+	if first.Request.PostData == nil || first.Request.PostData.Text != "payload" {
+		t.Error("request body not recorded; Received:", first.Request.PostData)
+	}
+	if len(first.Response.Cookies) != 1 || first.Response.Cookies[0].Name != "step" {
+		t.Error("response cookies mismatch; Received:", first.Response.Cookies)
+	}
+	var names []string
+	for _, header := range first.Request.Headers {
+		names = append(names, strings.ToLower(header.Name))
+	}
+	if order := strings.Join(names, ","); !strings.Contains(order, "x-second,x-first") {
+		t.Error("request headers not in wire order; Received:", order)
+	}
+	if first.Timings.Connect < 0 || first.Timings.Ssl < 0 {
+		t.Error("connection timings missing; Received:", first.Timings)
+	}
+
+	if last.Response.Content.Text != "finished" || !strings.Contains(last.Response.Content.Comment, "gzip") {
+		t.Error("response content mismatch; Received:", last.Response.Content)
+	}
+	if len(last.Request.Cookies) != 1 || last.Request.Cookies[0].Value != "1" {
+		t.Error("request cookies mismatch; Received:", last.Request.Cookies)
+	}
+	if len(last.Request.QueryString) != 1 || last.Request.QueryString[0].Name != "done" {
+		t.Error("query string mismatch; Received:", last.Request.QueryString)
+	}
+}
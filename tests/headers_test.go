@@ -0,0 +1,38 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMultiValueHeaders(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, v := range r.Header.Values("X-Tag") {
+			w.Header().Add("Link", "<"+v+">")
+		}
+		http.SetCookie(w, &http.Cookie{Name: "a", Value: "1"})
+		http.SetCookie(w, &http.Cookie{Name: "b", Value: "2"})
+	}))
+	defer server.Close()
+
+	resp, err := client.R().AddHeader("X-Tag", "one").AddHeader("X-Tag", "two").Get(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if links := strings.Join(resp.Headers.Values("Link"), ","); links != "<one>,<two>" {
+		t.Error("Link values mismatch; Received:", links)
+	}
+	if cookies := resp.Headers.Values("Set-Cookie"); len(cookies) != 2 {
+		t.Error("Set-Cookie values mismatch; Received:", cookies)
+	}
+}
+
+func TestInvalidPseudoHeaderOrder(t *testing.T) {
+	_, err := client.R().SetPseudoHeaderOrder(":method", ":path").Get("https://127.0.0.1:1/")
+	if err == nil || !strings.Contains(err.Error(), "pseudo header order") {
+		t.Error("incomplete pseudo header order accepted; Received:", err)
+	}
+}
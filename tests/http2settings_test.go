@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/tlshttptest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	safariHTTP2  = "2:0;4:4194304;3:100|10485760|0|m,s,p,a"
+	firefoxHTTP2 = "1:65536;4:131072;5:16384|12517377|3:0:0:201,5:0:0:101,7:0:0:1,9:0:7:1,11:0:3:1,13:0:0:241|m,p,a,s"
+)
+
+func TestHTTP2Settings(t *testing.T) {
+	server := tlshttptest.NewServer()
+	defer server.Close()
+
+	for _, akamai := range []string{safariHTTP2, firefoxHTTP2, "1:4096;4:65535|00|0|m,a,s,p", "1:1048576;4:1024|00|15:0:0:201,21:1:15:32|m,a,s,p"} {
+		settings, err := cycletls.ParseHTTP2Settings(akamai)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if settings.String() != akamai {
+			t.Error("Akamai round trip mismatch; Received:", settings.String())
+			continue
+		}
+
+		c := tlsHttpClient.New()
+		if err := c.SetHTTP2Settings(akamai); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.R().Get(server.URL); err != nil {
+			t.Error(akamai, err)
+			continue
+		}
+		fingerprint, _ := server.Last()
+		if fingerprint.HTTP2 == nil || fingerprint.HTTP2.Akamai != akamai {
+			t.Error("HTTP/2 fingerprint mismatch; Expected:", akamai, "Received:", fingerprint.HTTP2)
+		}
+	}
+
+	c := tlsHttpClient.New()
+	if _, err := c.R().SetHTTP2Settings(safariHTTP2).Get(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if fingerprint, _ := server.Last(); fingerprint.HTTP2.Akamai != safariHTTP2 {
+		t.Error("request HTTP/2 fingerprint mismatch; Received:", fingerprint.HTTP2.Akamai)
+	}
+	if c.HTTP2Settings != nil {
+		t.Error("request HTTP/2 settings leaked into the client")
+	}
+
+	invalid := []string{
+		"1:65536|15663105",
+		"1:65536;1:4096|00|0",
+		"4:2147483648|00|0",
+		"2:2|00|0",
+		"5:1024|00|0",
+		"1:65536|2147483647|0",
+		"1:65536|00|0:0:0:201",
+		"1:65536|00|3:0:0:257",
+		"1:65536|00|3:2:0:201",
+		"1:65536|00|0|m,a,s",
+		"1:65536|00|0|m,a,s,x",
+		"1=65536|00|0",
+	}
+	for _, input := range invalid {
+		if err := c.SetHTTP2Settings(input); !errors.Is(err, tlsHttpClient.ErrInvalidHTTP2Settings) {
+			t.Error("expected invalid HTTP/2 settings for", input, "; Received:", err)
+		}
+	}
+}
+
+// TestHTTP2SettingsFlowControl downloads more than the announced connection
+// window, fhttp has to keep returning flow control credit for it.
+func TestHTTP2SettingsFlowControl(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789abcdef"), 1<<20)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			w.WriteHeader(http.StatusHTTPVersionNotSupported)
+			return
+		}
+		_, _ = w.Write(payload)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	for _, akamai := range []string{"1:65536;4:131072|00|0|m,a,s,p", "4:1024|00|0|m,a,s,p", safariHTTP2, firefoxHTTP2} {
+		c := tlsHttpClient.New()
+		if err := c.SetHTTP2Settings(akamai); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.R().Get(server.URL)
+		if err != nil {
+			t.Error(akamai, err)
+			continue
+		}
+		if resp.StatusCode != 200 || !bytes.Equal(resp.Bytes, payload) {
+			t.Error(akamai, "body mismatch; StatusCode:", resp.StatusCode, "length:", len(resp.Bytes))
+		}
+	}
+}
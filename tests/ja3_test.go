@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"errors"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/tlshttptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseJA3(t *testing.T) {
+	ja3, err := cycletls.ParseJA3(tlsHttpClient.ChromeJA3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ja3.Version != 771 || len(ja3.Ciphers) != 15 || len(ja3.Extensions) != 16 || len(ja3.Curves) != 3 || len(ja3.PointFormats) != 1 {
+		t.Error("parsed JA3 mismatch; Received:", ja3)
+		return
+	}
+	if ja3.String() != tlsHttpClient.ChromeJA3 {
+		t.Error("JA3 round trip mismatch; Received:", ja3.String())
+		return
+	}
+
+	server := tlshttptest.NewServer()
+	defer server.Close()
+	if _, err := tlsHttpClient.New().R().Get(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if fingerprint, _ := server.Last(); fingerprint.JA3Hash != ja3.Hash() {
+		t.Error("JA3 hash mismatch; Expected:", fingerprint.JA3Hash, "Received:", ja3.Hash())
+		return
+	}
+
+	invalid := map[string]string{
+		"771":                        "want 5 comma separated fields, got 1",
+		"abc,4865,0,29,0":            `version[0] "abc": invalid syntax`,
+		"771,,0,29,0":                "ciphers: no cipher suites",
+		"771,4865--4866,0,29,0":      `ciphers[1] "": empty value`,
+		"771,4865,0,29,256":          `pointFormats[0] "256": value out of range`,
+		"771,4865,0-23-0,29,0":       `extensions[2] "0": duplicate extension`,
+		"771,4865,0-99999,29,0":      `extensions[1] "99999": value out of range`,
+		"771,4865-70000,0-23,29-23,": `ciphers[1] "70000": value out of range`,
+	}
+	for input, want := range invalid {
+		_, err := cycletls.ParseJA3(input)
+		if err == nil || !errors.Is(err, cycletls.ErrInvalidJA3) || !strings.HasSuffix(err.Error(), want) {
+			t.Error("JA3 error mismatch for", input, "; Received:", err)
+		}
+	}
+	_, err = cycletls.ParseJA3("771,4865,0-1234,29,0")
+	if !errors.Is(err, cycletls.ErrUnsupportedExtension) || !errors.Is(err, cycletls.ErrInvalidJA3) {
+		t.Error("expected unsupported extension; Received:", err)
+	}
+	if _, err := cycletls.StringToSpec("771,4865", ""); !errors.Is(err, cycletls.ErrInvalidJA3) {
+		t.Error("expected StringToSpec to reject a short JA3; Received:", err)
+	}
+}
+
+func TestJA3Diff(t *testing.T) {
+	a, _ := cycletls.ParseJA3("771,4865-4866-4867,0-23-65281,29-23,0")
+	b, _ := cycletls.ParseJA3("771,4866-4865-49195,0-23-65281,29-23,0")
+	if diff := a.Diff(a); diff != nil {
+		t.Error("expected no difference; Received:", diff)
+		return
+	}
+	diff := a.Diff(b)
+	if len(diff) != 1 || diff[0].String() != "ciphers: removed 4867, added 49195, reordered" {
+		t.Error("JA3 diff mismatch; Received:", diff)
+	}
+}
+
+func TestSetJA3Validation(t *testing.T) {
+	c := tlsHttpClient.New()
+	c.SetJA3("771,4865,0-1234,29,0")
+	if c.Ja3 != tlsHttpClient.ChromeJA3 {
+		t.Error("invalid JA3 was stored; Received:", c.Ja3)
+		return
+	}
+	if _, err := c.R().Get("https://127.0.0.1:1/"); !errors.Is(err, tlsHttpClient.ErrUnsupportedExtension) {
+		t.Error("expected unsupported extension from Execute; Received:", err)
+		return
+	}
+	path := filepath.Join(t.TempDir(), "file")
+	if _, err := c.R().Download("https://127.0.0.1:1/", path); !errors.Is(err, tlsHttpClient.ErrUnsupportedExtension) {
+		t.Error("expected unsupported extension from Download; Received:", err)
+		return
+	}
+	c.SetJA3(tlsHttpClient.ChromeJA3)
+	if _, err := c.R().SetJA3("771,4865").Get("https://127.0.0.1:1/"); !errors.Is(err, tlsHttpClient.ErrInvalidJA3) {
+		t.Error("expected invalid JA3 from Execute; Received:", err)
+	}
+}
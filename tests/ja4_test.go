@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"errors"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/tlshttptest"
+	"testing"
+)
+
+const firefoxJA4R = "t13d1714h2_002f,0035,009c,009d,1301,1302,1303,c009,c00a,c013,c014,c02b,c02c,c02f,c030,cca8,cca9_0005,000a,000b,000d,0017,001c,0022,0023,002b,002d,0033,ff01_0403,0503,0603,0804,0805,0806,0401,0501,0601,0203,0201"
+
+func TestJA4FromSpec(t *testing.T) {
+	server := tlshttptest.NewServer()
+	defer server.Close()
+	if _, err := tlsHttpClient.New().R().Get(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	fingerprint, _ := server.Last()
+
+	spec, err := cycletls.StringToSpec(tlsHttpClient.ChromeJA3, tlsHttpClient.ChromeUserAgent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ja4, err := cycletls.JA4FromSpec(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ja4.String() != fingerprint.JA4 || ja4.Raw() != fingerprint.JA4R {
+		t.Error("JA4 mismatch; Expected:", fingerprint.JA4R, "Received:", ja4.Raw())
+	}
+}
+
+func TestSetJA4(t *testing.T) {
+	parsed, err := cycletls.ParseJA4(firefoxJA4R)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Raw() != firefoxJA4R {
+		t.Error("JA4_r round trip mismatch; Received:", parsed.Raw())
+		return
+	}
+
+	server := tlshttptest.NewServer()
+	defer server.Close()
+	c := tlsHttpClient.New()
+	if err := c.SetJA4(firefoxJA4R); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.R().Get(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	fingerprint, _ := server.Last()
+	if fingerprint.JA4R != firefoxJA4R || fingerprint.JA4 != parsed.String() {
+		t.Error("JA4 on the wire mismatch; Expected:", firefoxJA4R, "Received:", fingerprint.JA4R)
+		return
+	}
+
+	if c.SetJA3(tlsHttpClient.ChromeJA3).Ja4 != "" {
+		t.Error("SetJA3 should replace the JA4; Received:", c.Ja4)
+		return
+	}
+
+	invalid := []string{
+		"t13d1516h2_8daaf6152771_e5627efa2ab1",
+		"t13d1715h2_002f_0005",
+		"q13d0101h2_1301_002b",
+		"t13d0102h2_1301_002b",
+		"t13d0104h2_1301_002b,04d2",
+		"t13d0101h2_13g1_002b",
+	}
+	for _, input := range invalid {
+		if err := c.SetJA4(input); !errors.Is(err, tlsHttpClient.ErrInvalidJA4) {
+			t.Error("expected invalid JA4 for", input, "; Received:", err)
+		}
+	}
+	if err := c.SetJA4("t13d0104h2_1301_002b,04d2"); !errors.Is(err, tlsHttpClient.ErrUnsupportedExtension) {
+		t.Error("expected unsupported extension; Received:", err)
+	}
+}
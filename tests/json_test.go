@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+)
+
+func newEchoServer() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+}
+
+func TestJsonObjectOrder(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+
+	body := tlsHttpClient.NewJsonObject().
+		Set("z", 1).
+		Set("a", "<b>").
+		Set("m", tlsHttpClient.NewJsonObject().Set("y", true).Set("b", nil)).
+		Set("list", []int{3, 1})
+
+	resp, err := client.R().SetJsonBody(body).Post(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := `{"z":1,"a":"<b>","m":{"y":true,"b":null},"list":[3,1]}`
+	if resp.Text != expected {
+		t.Error("json order mismatch; Received:", resp.Text, " Expected:", expected)
+	}
+
+	decoded, err := tlsHttpClient.Decode[*tlsHttpClient.JsonObject](resp)
+	if err != nil {
+		t.Error(err)
+	} else if keys := strings.Join(decoded.Keys(), ","); keys != "z,a,m,list" {
+		t.Error("decoded key order mismatch; Received:", keys)
+	}
+}
+
+func TestJsonBodyStructAndRaw(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+
+	resp, err := client.R().SetJsonBody([]apiItem{{ID: 1, Name: "one"}}).Post(server.URL)
+	if err != nil {
+		t.Error(err)
+	} else if resp.Text != `[{"id":1,"name":"one"}]` {
+		t.Error("struct body mismatch; Received:", resp.Text)
+	}
+
+	resp, err = client.R().SetJsonBody(json.RawMessage(`{"b":1,  "a":2}`)).Post(server.URL)
+	if err != nil {
+		t.Error(err)
+	} else if resp.Text != `{"b":1,  "a":2}` {
+		t.Error("raw body mismatch; Received:", resp.Text)
+	}
+}
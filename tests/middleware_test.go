@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+)
+
+func TestMiddleware(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Signature") != "signed" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	hooked := tlsHttpClient.New().
+		OnBeforeRequest(func(c *tlsHttpClient.Client, r *tlsHttpClient.Request) error {
+			r.SetHeader("X-Signature", "signed")
+			return nil
+		}).
+		OnAfterResponse(func(c *tlsHttpClient.Client, resp *tlsHttpClient.Response) (*tlsHttpClient.Response, error) {
+			replaced := *resp
+			replaced.Text = resp.Text + " (checked)"
+			return &replaced, nil
+		})
+
+	resp, err := hooked.R().Get(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Error("request middleware not applied; StatusCode:", resp.StatusCode)
+	}
+	if resp.Text != "ok (checked)" {
+		t.Error("response middleware not applied; Text:", resp.Text)
+	}
+}
+
+func TestMiddlewareError(t *testing.T) {
+	errAborted := errors.New("aborted")
+	var reported error
+	hooked := tlsHttpClient.New().
+		OnBeforeRequest(func(c *tlsHttpClient.Client, r *tlsHttpClient.Request) error {
+			return errAborted
+		}).
+		OnError(func(r *tlsHttpClient.Request, err error) {
+			reported = err
+		})
+
+	_, err := hooked.R().Get("https://127.0.0.1:1")
+	if !errors.Is(err, errAborted) || !errors.Is(reported, errAborted) {
+		t.Error("middleware error not reported; Received:", err, reported)
+	}
+}
+
+func TestMultipartMethodError(t *testing.T) {
+	var reported error
+	hooked := tlsHttpClient.New().
+		OnError(func(r *tlsHttpClient.Request, err error) {
+			reported = err
+		})
+
+	_, err := hooked.R().SetMultipartFormData(map[string]string{"a": "b"}).Get("https://127.0.0.1:1")
+	if err == nil || reported != err {
+		t.Error("multipart method error not reported; Received:", err, reported)
+	}
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMultipartBuilder(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.ContentLength != int64(len(body)) {
+			w.WriteHeader(http.StatusLengthRequired)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		parts, err := r.MultipartReader()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for {
+			part, err := parts.NextPart()
+			if err != nil {
+				break
+			}
+			content, _ := io.ReadAll(part)
+			_, _ = fmt.Fprintf(w, "%s|%s|%s|%s\n", part.FormName(), part.FileName(), part.Header.Get("Content-Type"), content)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "image.png")
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	if err := os.WriteFile(path, png, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.R().
+		SetMultipartValue("z", "last").
+		SetMultipartFile("file", path).
+		SetMultipartField("blob", "data", "", strings.NewReader("%PDF-1.4 body")).
+		SetMultipartValue("a", "first").
+		Post(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := "z|||last\n" +
+		"file|image.png|image/png|" + string(png) + "\n" +
+		"blob|data|application/pdf|%PDF-1.4 body\n" +
+		"a|||first\n"
+	if resp.StatusCode != http.StatusOK || resp.Text != expected {
+		t.Error("multipart mismatch; StatusCode:", resp.StatusCode, " Received:", resp.Text)
+	}
+}
+
+func TestMultipartErrors(t *testing.T) {
+	_, err := client.R().SetMultipartBoundary("bad boundary\n").SetMultipartValue("a", "b").Post("https://127.0.0.1:1/")
+	if err == nil || !strings.Contains(err.Error(), "boundary") {
+		t.Error("invalid boundary accepted; Received:", err)
+	}
+
+	_, err = client.R().SetMultipartFile("file", filepath.Join(t.TempDir(), "missing")).Post("https://127.0.0.1:1/")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("missing file accepted; Received:", err)
+	}
+}
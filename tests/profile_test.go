@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/tlshttptest"
+	"strings"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	server := tlshttptest.NewServer()
+	defer server.Close()
+
+	for _, profile := range tlsHttpClient.Profiles {
+		c := tlsHttpClient.New()
+		if err := c.SetProfile(profile); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.R().Get(server.URL + "/" + profile.Name); err != nil {
+			t.Error(profile.Name, err)
+			continue
+		}
+		fingerprint, _ := server.Last()
+
+		if fingerprint.JA3 != profile.JA3 {
+			t.Error(profile.Name, "JA3 mismatch; Received:", fingerprint.JA3)
+		}
+		grease := fingerprint.ClientHello.CipherSuites[0]&0x0f0f == 0x0a0a
+		if grease != (profile.Browser != "firefox") {
+			t.Error(profile.Name, "GREASE mismatch; Received:", fingerprint.ClientHello.CipherSuites)
+		}
+		if fingerprint.HTTP2 == nil || strings.Join(fingerprint.HTTP2.PseudoHeaderOrder, ",") != strings.Join(profile.PHeaderOrder, ",") {
+			t.Error(profile.Name, "pseudo header order mismatch; Received:", fingerprint.HTTP2)
+		}
+		if fingerprint.HTTP2.Akamai != profile.HTTP2 {
+			t.Error(profile.Name, "HTTP/2 fingerprint mismatch; Received:", fingerprint.HTTP2.Akamai)
+		}
+
+		wantMobile := "?0"
+		if strings.HasSuffix(profile.Name, "-android") {
+			wantMobile = "?1"
+		}
+		if profile.Browser == "chrome" && c.Props.Headers.Get("Sec-Ch-Ua-Mobile") != wantMobile {
+			t.Error(profile.Name, "sec-ch-ua-mobile mismatch; Received:", c.Props.Headers.Get("Sec-Ch-Ua-Mobile"))
+		}
+
+		var want []string
+		for _, name := range profile.HeaderOrder {
+			if c.Props.Headers.Get(name) != "" {
+				want = append(want, name)
+			}
+		}
+		if got := strings.Join(fingerprint.HeaderOrder, ","); got != strings.Join(want, ",") {
+			t.Error(profile.Name, "header order mismatch; Expected:", want, "Received:", got)
+		}
+	}
+}
+
+func TestRequestProfile(t *testing.T) {
+	server := tlshttptest.NewServer()
+	defer server.Close()
+
+	profile, ok := tlsHttpClient.ProfileByName("firefox-115-windows")
+	if !ok {
+		t.Fatal("profile not found")
+	}
+	c := tlsHttpClient.New()
+	if _, err := c.R().SetProfile(profile).Get(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	fingerprint, _ := server.Last()
+	if fingerprint.JA3 != profile.JA3 || fingerprint.HeaderOrder[0] != "user-agent" {
+		t.Error("request profile mismatch; Received:", fingerprint.JA3, fingerprint.HeaderOrder)
+		return
+	}
+	if c.Ja3 != tlsHttpClient.ChromeJA3 || c.Browser != "" {
+		t.Error("request profile leaked into the client; Received:", c.Ja3, c.Browser)
+	}
+}
+
+// TestFirefoxConnectionClose sends the default Connection: close header over
+// HTTP/2 with fhttp's Firefox preset.
+func TestFirefoxConnectionClose(t *testing.T) {
+	server := tlshttptest.NewServer()
+	defer server.Close()
+
+	c := tlsHttpClient.New()
+	c.Browser = "firefox"
+	if c.Props.Headers.Get("Connection") != "close" {
+		t.Fatal("expected a default Connection: close header; Received:", c.Props.Headers.Get("Connection"))
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := c.R().Get(server.URL); err != nil {
+			t.Fatal(err)
+		}
+		if fingerprint, _ := server.Last(); fingerprint.HTTP2 == nil {
+			t.Fatal("request was not sent over HTTP/2")
+		}
+	}
+}
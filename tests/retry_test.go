@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+)
+
+func TestRetryStatusCode(t *testing.T) {
+	var calls int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var hooks int
+	policy := tlsHttpClient.NewRetryPolicy().
+		SetWaitTime(10*time.Millisecond, 50*time.Millisecond).
+		AddHook(func(attempt int, resp *tlsHttpClient.Response, err error) {
+			hooks++
+		})
+
+	resp, err := client.R().SetAttempts(5).SetRetryPolicy(policy).Get(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if resp.StatusCode != http.StatusOK || resp.Attempts != 3 {
+		t.Error("retry mismatch; StatusCode:", resp.StatusCode, " Attempts:", resp.Attempts)
+	}
+	if hooks != 2 {
+		t.Error("retry hook count mismatch; Received:", hooks, " Expected: 2")
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	var calls int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	resp, err := client.R().SetAttempts(3).SetRetryPolicy(tlsHttpClient.NewRetryPolicy()).Post(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if resp.Attempts != 1 || atomic.LoadInt32(&calls) != 1 {
+		t.Error("POST was retried; Attempts:", resp.Attempts)
+	}
+}
+
+func TestRetryBodyReader(t *testing.T) {
+	var calls int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	policy := tlsHttpClient.NewRetryPolicy().SetWaitTime(0, 0).SetRetryNonIdempotent(true)
+	payload := bytes.Repeat([]byte("body"), 1024)
+	resp, err := client.R().SetAttempts(3).SetRetryPolicy(policy).
+		SetBodyReader(bytes.NewReader(payload), int64(len(payload))).
+		Post(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if resp.Attempts != 2 || !bytes.Equal(resp.Bytes, payload) {
+		t.Error("seekable body was not resent; Attempts:", resp.Attempts, " length:", len(resp.Bytes))
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	resp, err = client.R().SetAttempts(3).SetRetryPolicy(policy).
+		SetBodyReader(io.MultiReader(bytes.NewReader(payload)), int64(len(payload))).
+		Post(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if resp.Attempts != 1 || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Error("consumed body was retried; Attempts:", resp.Attempts, " StatusCode:", resp.StatusCode)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	resp, err = client.R().SetAttempts(3).SetRetryPolicy(policy).
+		SetMultipartField("file", "body.bin", "", bytes.NewReader(payload)).
+		Post(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if resp.Attempts != 2 || !bytes.Contains(resp.Bytes, payload) {
+		t.Error("seekable multipart field was not resent; Attempts:", resp.Attempts)
+	}
+
+	path := filepath.Join(t.TempDir(), "body.bin")
+	if err := os.WriteFile(path, payload, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	atomic.StoreInt32(&calls, 0)
+	resp, err = client.R().SetAttempts(3).SetRetryPolicy(policy).
+		SetBodyReader(file, int64(len(payload))).
+		Post(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if resp.Attempts != 2 || !bytes.Equal(resp.Bytes, payload) {
+		t.Error("file body was not resent; Attempts:", resp.Attempts, " length:", len(resp.Bytes))
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Error("caller's file was closed:", err)
+	}
+}
+
+func TestRetryErrors(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	var retries int
+	policy := tlsHttpClient.NewRetryPolicy().SetWaitTime(0, 0).
+		AddHook(func(attempt int, resp *tlsHttpClient.Response, err error) {
+			retries++
+		})
+	if _, err := client.R().SetAttempts(3).SetRetryPolicy(policy).Get(url); err == nil {
+		t.Error("expected a connection error")
+	}
+	if retries != 2 {
+		t.Error("connection error retry count mismatch; Received:", retries, " Expected: 2")
+	}
+
+	retries = 0
+	if _, err := client.R().SetAttempts(3).SetRetryPolicy(policy).SetPseudoHeaderOrder("x").Get(url); err == nil {
+		t.Error("expected an invalid pseudo header order error")
+	}
+	if retries != 0 {
+		t.Error("invalid request was retried; Received:", retries)
+	}
+
+	retries = 0
+	var seen error
+	policy.AddCondition(func(resp *tlsHttpClient.Response, err error) bool {
+		seen = err
+		return err != nil
+	})
+	if _, err := client.R().SetAttempts(3).SetRetryPolicy(policy).SetPseudoHeaderOrder("x").Get(url); err == nil {
+		t.Error("expected an invalid pseudo header order error")
+	}
+	if seen == nil || retries != 2 {
+		t.Error("condition did not see the error; Received:", seen, " retries:", retries)
+	}
+
+	retries = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.R().SetContext(ctx).SetAttempts(3).SetRetryPolicy(policy).Get(url); !errors.Is(err, context.Canceled) {
+		t.Error("expected context.Canceled; Received:", err)
+	}
+	if retries != 0 {
+		t.Error("canceled request was retried; Received:", retries)
+	}
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+)
+
+func TestStreamGzip(t *testing.T) {
+	payload := bytes.Repeat([]byte("streamed body "), 1<<16)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write(payload)
+		_ = gz.Close()
+	}))
+	defer server.Close()
+
+	resp, err := client.R().SetDoNotParseResponse(true).Get(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if len(resp.Bytes) != 0 {
+		t.Error("body was buffered")
+	}
+	received, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	} else if !bytes.Equal(received, payload) {
+		t.Error("streamed body mismatch; Received:", len(received), " Expected:", len(payload))
+	}
+}
+
+func TestStreamTimeout(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delay := 400 * time.Millisecond
+		if r.URL.Path == "/stall" {
+			delay = 2 * time.Second
+		}
+		for i := 0; i < 4; i++ {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	resp, err := client.R().SetTimeout(1).SetDoNotParseResponse(true).Get(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	received, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Error("slow body hit the timeout:", err)
+	} else if string(received) != strings.Repeat("chunk", 4) {
+		t.Error("streamed body mismatch; Received:", string(received))
+	}
+
+	resp, err = client.R().SetTimeout(1).SetDoNotParseResponse(true).Get(server.URL + "/stall")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); !errors.Is(err, tlsHttpClient.ErrTimeout) {
+		t.Error("expected ErrTimeout for an idle body; Received:", err)
+	}
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func newUploadServer() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Content-Length", strconv.FormatInt(r.ContentLength, 10))
+		_, _ = w.Write(body)
+	}))
+}
+
+func TestUploadFile(t *testing.T) {
+	server := newUploadServer()
+	defer server.Close()
+
+	payload := bytes.Repeat([]byte("file body "), 1<<14)
+	path := filepath.Join(t.TempDir(), "upload.bin")
+	if err := os.WriteFile(path, payload, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.R().SetBodyFile(path).Post(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(resp.Bytes, payload) {
+		t.Error("file body mismatch; Received:", len(resp.Bytes), " Expected:", len(payload))
+	}
+	if resp.Headers.Get("X-Content-Length") != strconv.Itoa(len(payload)) {
+		t.Error("content length mismatch; Received:", resp.Headers.Get("X-Content-Length"))
+	}
+}
+
+func TestUploadChunked(t *testing.T) {
+	server := newUploadServer()
+	defer server.Close()
+
+	payload := strings.Repeat("chunked body ", 1<<12)
+	// hide the concrete reader type so its length cannot be detected
+	reader := io.MultiReader(strings.NewReader(payload))
+
+	resp, err := client.R().SetBodyReader(reader, -1).Post(server.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if resp.Text != payload {
+		t.Error("chunked body mismatch; Received:", len(resp.Text), " Expected:", len(payload))
+	}
+	if resp.Headers.Get("X-Content-Length") != "-1" {
+		t.Error("body was not sent chunked; Content-Length:", resp.Headers.Get("X-Content-Length"))
+	}
+}
+
+func TestUploadFileClosedOnError(t *testing.T) {
+	descriptors, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("open descriptors can not be counted:", err)
+	}
+	path := filepath.Join(t.TempDir(), "upload.bin")
+	if err := os.WriteFile(path, []byte("file body"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 20; i++ {
+		// the pseudo header order is rejected after the file is opened
+		if _, err := client.R().SetBodyFile(path).SetPseudoHeaderOrder("x").Post("https://127.0.0.1/"); err == nil {
+			t.Fatal("expected an invalid pseudo header order")
+		}
+	}
+	after, _ := os.ReadDir("/proc/self/fd")
+	if len(after) > len(descriptors) {
+		t.Error("body file leaked; open descriptors before:", len(descriptors), " after:", len(after))
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient"
+)
+
+func TestExportUrl(t *testing.T) {
+	c := tlsHttpClient.New().SetBaseURL("https://пример.рф/api/").SetQueryParam("token", "t")
+
+	r := c.R().SetPathParam("id", "a b").SetQueryString("z=1&a=2&a=3").AddQueryParam("m", "4")
+	r.URL = "/users/{id}?b=0&a=9#top"
+	exported, err := r.ExportUrl()
+	if err != nil {
+		t.Error(err)
+	}
+	expected := "https://xn--e1afmkfd.xn--p1ai/api/users/a%20b?b=0&a=2&a=3&token=t&z=1&m=4#top"
+	if exported != expected {
+		t.Error("url mismatch; Received:", exported, " Expected:", expected)
+	}
+}
+
+func TestQueryOrder(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.RawQuery))
+	}))
+	defer server.Close()
+
+	resp, err := client.R().SetQueryString("z=1&a=2&a=3").AddQueryParam("m", "4").Get(server.URL + "/?b=0")
+	if err != nil {
+		t.Error(err)
+	} else if resp.Text != "b=0&z=1&a=2&a=3&m=4" {
+		t.Error("query order mismatch; Received:", resp.Text)
+	}
+}
+
+func TestFormOrder(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	resp, err := client.R().
+		SetFormValue("username", "user").
+		SetFormValue("password", "p&ss").
+		AddFormValue("tags", "a").
+		AddFormValue("tags", "b").
+		SetFormValue("username", "admin").
+		Post(server.URL)
+	if err != nil {
+		t.Error(err)
+	} else if resp.Text != "username=admin&password=p%26ss&tags=a&tags=b" {
+		t.Error("form order mismatch; Received:", resp.Text)
+	}
+}
+
+func TestExportUrlRaw(t *testing.T) {
+	c := tlsHttpClient.New().SetBaseURL("https://example.org/api")
+
+	r := c.R().SetPathParam("a", "{b}").SetPathParam("b", "x")
+	r.URL = "/login/{a}/{b}/{c}?next=https://example.com/home"
+	exported, err := r.ExportUrl()
+	if err != nil {
+		t.Error(err)
+	} else if expected := "https://example.org/api/login/%7Bb%7D/x/%7Bc%7D?next=https://example.com/home"; exported != expected {
+		t.Error("url mismatch; Received:", exported, " Expected:", expected)
+	}
+
+	r = c.R().AddQueryParam("x", "1").SetQueryParam("a", "b c")
+	r.URL = "https://example.com/?flag&a=%7e&b=%7e"
+	exported, err = r.ExportUrl()
+	if err != nil {
+		t.Error(err)
+	} else if expected := "https://example.com/?flag&a=b+c&b=%7e&x=1"; exported != expected {
+		t.Error("raw query mismatch; Received:", exported, " Expected:", expected)
+	}
+}
+
+func TestBaseURLQuery(t *testing.T) {
+	c := tlsHttpClient.New().SetBaseURL("https://example.org/api/?key=k&v=1")
+
+	r := c.R().SetQueryParam("v", "2").SetQueryParam("page", "3")
+	r.URL = "/users/%2F?sort=name#top"
+	exported, err := r.ExportUrl()
+	if err != nil {
+		t.Error(err)
+	} else if expected := "https://example.org/api/users/%2F?key=k&v=2&sort=name&page=3#top"; exported != expected {
+		t.Error("url mismatch; Received:", exported, " Expected:", expected)
+	}
+
+	r = c.R()
+	r.URL = ""
+	exported, err = r.ExportUrl()
+	if err != nil {
+		t.Error(err)
+	} else if expected := "https://example.org/api/?key=k&v=1"; exported != expected {
+		t.Error("url mismatch; Received:", exported, " Expected:", expected)
+	}
+}
@@ -15,20 +15,42 @@ const (
 	MethodOptions = "OPTIONS"
 )
 
+// Header holds multi-value headers, see cycletls.Header.
+type Header = cycletls.Header
+
 type RequestProps struct {
-	QueryParam      map[string]string
-	Headers         map[string]string
-	Cookies         []cycletls.Cookie
+	QueryParam      Params
+	PathParams      map[string]string
+	Headers         Header
+	HeaderOrder     []string
+	PHeaderOrder    []string
 	DisableRedirect bool
 }
 
 type Client struct {
-	CycleTLS *cycletls.CycleTLS
-	Ja3      string
-	Attempts int
-	Timeout  int
-	Props    RequestProps
-	proxy    *Proxy
+	CycleTLS    *cycletls.CycleTLS
+	BaseURL     string
+	Ja3         string
+	Ja4         string
+	Browser     string
+	Attempts    int
+	Timeout     int
+	RetryPolicy *RetryPolicy
+	Props       RequestProps
+	proxy       *Proxy
+	har         *HARRecorder
+
+	// HTTP2Settings, when set, replace the HTTP/2 preset of the browser
+	HTTP2Settings *cycletls.HTTP2Settings
+
+	codecs        map[string]Codec
+	beforeRequest []RequestMiddleware
+	afterResponse []ResponseMiddleware
+	errorHooks    []ErrorHook
+	retryHooks    []RetryHookFunc
+
+	// err is the error of the last SetJA3, requests return it from Execute
+	err error
 }
 
 //goland:noinspection ALL
@@ -39,13 +61,18 @@ func New() *Client {
 		Ja3:      ChromeJA3,
 		Attempts: defaultAttempts,
 		Timeout:  defaultTimeout,
+		// nil keeps retrying failed attempts immediately
+		RetryPolicy: nil,
 		Props: RequestProps{
-			QueryParam:      map[string]string{},
+			QueryParam:      Params{},
+			PathParams:      map[string]string{},
 			Headers:         newDefaultHeaders(),
-			Cookies:         []cycletls.Cookie{},
+			HeaderOrder:     nil,
+			PHeaderOrder:    nil,
 			DisableRedirect: defaultDisableRedirect,
 		},
-		proxy: nil,
+		proxy:  nil,
+		codecs: newDefaultCodecs(),
 	}
 }
 
@@ -54,11 +81,47 @@ func (c *Client) SetDisableRedirect(value bool) *Client {
 	return c
 }
 
+// SetJA3 sets the TLS fingerprint of the client. An invalid ja3, see
+// cycletls.ParseJA3, is not stored and requests of the client return its
+// error from Execute until SetJA3 is called with a valid one.
 func (c *Client) SetJA3(ja3 string) *Client {
+	if _, err := cycletls.ParseJA3(ja3); err != nil {
+		c.err = err
+		return c
+	}
+	c.err = nil
 	c.Ja3 = ja3
+	c.Ja4 = ""
 	return c
 }
 
+// SetJA4 sets the TLS fingerprint of the client from a JA4_r string, see
+// cycletls.JA4ToSpec for what JA4 leaves open. It replaces the JA3 until
+// SetJA3 is called again.
+func (c *Client) SetJA4(ja4r string) error {
+	if _, err := cycletls.ParseJA4(ja4r); err != nil {
+		return err
+	}
+	c.Ja4 = ja4r
+	return nil
+}
+
+// SetHTTP2Settings sets the HTTP/2 fingerprint of the client from an Akamai
+// fingerprint, see cycletls.ParseHTTP2Settings. The empty string goes back
+// to the preset of the browser.
+func (c *Client) SetHTTP2Settings(akamai string) error {
+	if akamai == "" {
+		c.HTTP2Settings = nil
+		return nil
+	}
+	settings, err := cycletls.ParseHTTP2Settings(akamai)
+	if err != nil {
+		return err
+	}
+	c.HTTP2Settings = settings
+	return nil
+}
+
 func (c *Client) SetProxy(proxy *Proxy) error {
 	if proxy == nil {
 		return errors.New("proxy is nil")
@@ -71,7 +134,13 @@ func (c *Client) SetProxy(proxy *Proxy) error {
 }
 
 func (c *Client) SetHeader(header, value string) *Client {
-	c.Props.Headers[header] = value
+	c.Props.Headers.Set(header, value)
+	return c
+}
+
+// AddHeader appends value to the values already set for header.
+func (c *Client) AddHeader(header, value string) *Client {
+	c.Props.Headers.Add(header, value)
 	return c
 }
 
@@ -83,20 +152,76 @@ func (c *Client) SetHeaders(headers map[string]string) *Client {
 }
 
 func (c *Client) ReplaceHeaders(headers map[string]string) *Client {
-	c.Props.Headers = make(map[string]string)
+	c.Props.Headers = Header{}
 	c.SetHeaders(headers)
 	return c
 }
 
+// SetHeaderOrder sets the order headers are sent in, names are matched case
+// insensitively and headers missing from it follow sorted by name. Custom
+// headers can be given a position by listing them. Without an order
+// cycletls.DefaultHeaderOrder is used.
+func (c *Client) SetHeaderOrder(order ...string) *Client {
+	c.Props.HeaderOrder = order
+	return c
+}
+
+// SetPseudoHeaderOrder sets the HTTP/2 pseudo header order, it must list
+// :method, :authority, :scheme and :path once each.
+func (c *Client) SetPseudoHeaderOrder(order ...string) *Client {
+	c.Props.PHeaderOrder = order
+	return c
+}
+
 func (c *Client) SetTimeout(timeout int) *Client {
 	c.Timeout = timeout
 	return c
 }
 
+func (c *Client) SetAttempts(attempts int) *Client {
+	c.Attempts = attempts
+	return c
+}
+
+// SetRetryPolicy sets how failed attempts are retried, see NewRetryPolicy.
+func (c *Client) SetRetryPolicy(policy *RetryPolicy) *Client {
+	c.RetryPolicy = policy
+	return c
+}
+
+// SetCassette records the client's requests to cassette or replays them from
+// it, see cycletls.NewCassette. nil sends requests again.
+func (c *Client) SetCassette(cassette *cycletls.Cassette) *Client {
+	c.CycleTLS.Cassette = cassette
+	return c
+}
+
 func (c *Client) SetQueryParams(queryParam map[string]string) {
-	for key, value := range queryParam {
-		c.Props.QueryParam[key] = value
-	}
+	c.Props.QueryParam.Merge(paramsFromMap(queryParam))
+}
+
+func (c *Client) SetQueryParam(param, value string) *Client {
+	c.Props.QueryParam.Set(param, value)
+	return c
+}
+
+// AddQueryParam appends a value for param, so the parameter repeats.
+func (c *Client) AddQueryParam(param, value string) *Client {
+	c.Props.QueryParam.Add(param, value)
+	return c
+}
+
+// SetBaseURL sets the URL relative request URLs are resolved against.
+func (c *Client) SetBaseURL(baseURL string) *Client {
+	c.BaseURL = baseURL
+	return c
+}
+
+// SetPathParam replaces {param} in the URL of every request with the escaped
+// value, request path parameters take precedence.
+func (c *Client) SetPathParam(param, value string) *Client {
+	c.Props.PathParams[param] = value
+	return c
 }
 
 func (c *Client) R() *Request {
@@ -105,41 +230,80 @@ func (c *Client) R() *Request {
 		Method:                 "",
 		URL:                    "",
 		QueryParam:             nil,
-		Headers:                map[string]string{},
+		PathParams:             nil,
+		Headers:                Header{},
+		HeaderOrder:            c.Props.HeaderOrder,
+		PHeaderOrder:           c.Props.PHeaderOrder,
+		Cookies:                nil,
 		Body:                   "",
+		BodyReader:             nil,
+		BodyLength:             0,
+		BodyFile:               "",
 		Forms:                  nil,
 		Json:                   nil,
-		Multipart:              nil,
-		MultipartBody:          nil,
+		JsonBody:               nil,
+		MultipartFields:        nil,
+		MultipartBoundary:      "",
 		DisableRedirect:        c.Props.DisableRedirect,
 		SetContentTypeDirectly: false,
+		DoNotParseResponse:     false,
+		DownloadProgress:       nil,
 		Proxy:                  c.proxy,
+		Ja3:                    c.Ja3,
+		Ja4:                    c.Ja4,
+		Browser:                c.Browser,
+		HTTP2Settings:          c.HTTP2Settings,
 		Attempts:               c.Attempts,
 		Timeout:                c.Timeout,
+		RetryPolicy:            c.RetryPolicy,
+		Result:                 nil,
+		Error:                  nil,
+		err:                    c.err,
 	}
 }
 
 func (c *Client) execute(r *Request) (*Response, error) {
-	url := r.ExportUrl()
-	body := r.ExportBody()
+	if err := c.runBeforeRequest(r); err != nil {
+		return nil, err
+	}
+	url, err := r.ExportUrl()
+	if err != nil {
+		return nil, err
+	}
+	body, bodyLength, err := r.ExportBody()
+	if err != nil {
+		return nil, err
+	}
 	headers, userAgent := r.ExportHeaders()
-	response, err := c.CycleTLS.Do(
+	response, err := c.CycleTLS.DoContext(
+		r.Context(),
 		url,
 		cycletls.Options{
 			URL:             url,
 			Method:          r.Method,
 			Headers:         headers,
-			Body:            body,
-			Ja3:             c.Ja3,
+			BodyReader:      body,
+			BodyLength:      bodyLength,
+			Ja3:             r.Ja3,
+			Ja4:             r.Ja4,
+			Browser:         r.Browser,
 			UserAgent:       userAgent,
 			Proxy:           r.ExportProxy(),
 			Timeout:         r.Timeout,
 			DisableRedirect: r.DisableRedirect,
-			HeaderOrder:     nil,
+			HeaderOrder:     r.HeaderOrder,
 			OrderAsProvided: false,
+			PHeaderOrder:    r.PHeaderOrder,
+			HTTP2Settings:   r.HTTP2Settings,
+
+			DoNotParseResponse: r.DoNotParseResponse,
+			RecordExchanges:    c.har != nil,
 		},
 		r.Method,
 	)
+	if c.har != nil {
+		c.har.record(response.Exchanges, response, r.DoNotParseResponse)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -150,10 +314,11 @@ func (c *Client) execute(r *Request) (*Response, error) {
 		StatusCode: response.StatusCode,
 		Headers:    response.Headers,
 		Cookies:    response.Cookies,
-	}
-	if len(response.Cookies) > 0 {
-		c.Props.Cookies = append(c.Props.Cookies, responseObj.Cookies...)
+		Body:       response.Body,
+		Request:    r,
+
+		ResponseCookies: response.ResponseCookies,
 	}
 
-	return responseObj, nil
+	return c.runAfterResponse(responseObj)
 }
@@ -0,0 +1,86 @@
+package tlsHttpClient
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"strings"
+)
+
+// Codec encodes and decodes bodies of one media type.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) Marshal(v any) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (xmlCodec) Unmarshal(data []byte, v any) error {
+	return xml.Unmarshal(data, v)
+}
+
+var (
+	JSONCodec Codec = jsonCodec{}
+	XMLCodec  Codec = xmlCodec{}
+)
+
+func newDefaultCodecs() map[string]Codec {
+	return map[string]Codec{
+		"application/json": JSONCodec,
+		"text/json":        JSONCodec,
+		"application/xml":  XMLCodec,
+		"text/xml":         XMLCodec,
+	}
+}
+
+// RegisterCodec makes responses of contentType decode with codec, for example
+// "application/msgpack". Parameters of contentType are ignored.
+func (c *Client) RegisterCodec(contentType string, codec Codec) *Client {
+	if c.codecs == nil {
+		c.codecs = newDefaultCodecs()
+	}
+	c.codecs[mediaType(contentType)] = codec
+	return c
+}
+
+// codec returns the codec registered for contentType. Structured syntax
+// suffixes such as +json fall back to the base codec, anything unknown is
+// decoded as JSON.
+func (c *Client) codec(contentType string) Codec {
+	codecs := c.codecs
+	if codecs == nil {
+		codecs = newDefaultCodecs()
+	}
+	media := mediaType(contentType)
+	if codec, ok := codecs[media]; ok {
+		return codec
+	}
+	if i := strings.LastIndexByte(media, '+'); i >= 0 {
+		if codec, ok := codecs["application/"+media[i+1:]]; ok {
+			return codec
+		}
+	}
+	return JSONCodec
+}
+
+func mediaType(contentType string) string {
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return media
+}
@@ -0,0 +1,119 @@
+package tlsHttpClient
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+
+	http "github.com/Danny-Dasilva/fhttp"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls"
+)
+
+// Cookie is an HTTP cookie, see cycletls.Cookie.
+type Cookie = cycletls.Cookie
+
+// cookieURL resolves rawURL against the base url like a request url.
+func (c *Client) cookieURL(rawURL string) (*url.URL, error) {
+	resolved, err := buildURL(c.BaseURL, rawURL, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return url.Parse(resolved)
+}
+
+// SetCookie stores cookie in the jar for its Domain and Path, as if a
+// response from that domain had set it. Domain is required, and an error is
+// returned when the jar rejects the cookie.
+func (c *Client) SetCookie(cookie Cookie) error {
+	domain := strings.TrimPrefix(cookie.Domain, ".")
+	if domain == "" {
+		return errors.New("cookie: domain is required, use SetCookies to set a cookie for an url")
+	}
+	// the jar would turn a domain cookie for a public suffix into a host cookie
+	if strings.Contains(domain, ".") && net.ParseIP(domain) == nil && cycletls.DefaultSuffixList().PublicSuffix(domain) == domain {
+		return errors.New("cookie: domain " + domain + " is a public suffix")
+	}
+	scheme := "http"
+	if cookie.Secure {
+		scheme = "https"
+	}
+	path := cookie.Path
+	if path == "" {
+		path = "/"
+	}
+	u := &url.URL{Scheme: scheme, Host: domain, Path: path}
+	return c.CycleTLS.SetCookies(u, []Cookie{cookie})
+}
+
+// SetCookies stores cookies in the jar as if a response from rawURL had set
+// them. A cookie without Domain is only sent back to the host of rawURL, the
+// error says why cookies were rejected.
+func (c *Client) SetCookies(rawURL string, cookies ...Cookie) error {
+	u, err := c.cookieURL(rawURL)
+	if err != nil {
+		return err
+	}
+	return c.CycleTLS.SetCookies(u, cookies)
+}
+
+// GetCookies returns the cookies the jar sends to rawURL.
+func (c *Client) GetCookies(rawURL string) ([]Cookie, error) {
+	u, err := c.cookieURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return c.CycleTLS.Cookies(u), nil
+}
+
+// DeleteCookie removes the cookie called name from those sent to rawURL.
+func (c *Client) DeleteCookie(rawURL, name string) error {
+	u, err := c.cookieURL(rawURL)
+	if err != nil {
+		return err
+	}
+	c.CycleTLS.DeleteCookie(u, name)
+	return nil
+}
+
+// SetCookieJar replaces the jar, for example with one made by
+// cycletls.NewFileJar to keep cookies between runs.
+func (c *Client) SetCookieJar(jar *cycletls.Jar) *Client {
+	c.CycleTLS.CookieJar = jar
+	return c
+}
+
+// ClearCookies removes every cookie from the jar.
+func (c *Client) ClearCookies() *Client {
+	c.CycleTLS.ClearCookies()
+	return c
+}
+
+// SetCookie sends cookie with this request only, the jar is left untouched.
+// Only Name and Value are used.
+func (r *Request) SetCookie(cookie Cookie) *Request {
+	r.Cookies = append(r.Cookies, cookie)
+	return r
+}
+
+// SetCookies sends cookies with this request only, see SetCookie.
+func (r *Request) SetCookies(cookies ...Cookie) *Request {
+	r.Cookies = append(r.Cookies, cookies...)
+	return r
+}
+
+// exportCookies appends the request cookies to the Cookie header, cookies
+// from the jar are added after them when the request is sent.
+func (r *Request) exportCookies(headers Header) {
+	if len(r.Cookies) == 0 {
+		return
+	}
+	pairs := make([]string, 0, len(r.Cookies)+1)
+	if existing := headers.Get("Cookie"); existing != "" {
+		pairs = append(pairs, existing)
+	}
+	for _, cookie := range r.Cookies {
+		pairs = append(pairs, (&http.Cookie{Name: cookie.Name, Value: cookie.Value}).String())
+	}
+	headers.Set("Cookie", strings.Join(pairs, "; "))
+}
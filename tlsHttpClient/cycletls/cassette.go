@@ -0,0 +1,368 @@
+package cycletls
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	http "github.com/Danny-Dasilva/fhttp"
+)
+
+// CassetteMode decides whether a Cassette sends requests or replays them.
+type CassetteMode int
+
+const (
+	// CassetteRecord sends every request and stores the exchange, the
+	// cassette file is rewritten from scratch.
+	CassetteRecord CassetteMode = iota
+	// CassetteReplay serves every request from the cassette and fails the
+	// ones that match no recorded interaction, nothing goes out on the
+	// network.
+	CassetteReplay
+	// CassetteReplayOrRecord replays the requests it can and records the
+	// others.
+	CassetteReplayOrRecord
+)
+
+const cassetteVersion = 1
+
+// redactedValue replaces the value of redacted headers.
+const redactedValue = "REDACTED"
+
+// CassetteRequest is the recorded form of a request.
+type CassetteRequest struct {
+	Method  string `json:"method"`
+	URL     string `json:"url"`
+	Headers Header `json:"headers,omitempty"`
+	Body    string `json:"body,omitempty"`
+}
+
+// CassetteResponse is the recorded form of a response, Body is decompressed
+// and base64 encoded when BodyEncoding is "base64".
+type CassetteResponse struct {
+	StatusCode   int    `json:"statusCode"`
+	Headers      Header `json:"headers,omitempty"`
+	Body         string `json:"body,omitempty"`
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
+}
+
+// Interaction is a request and the response it got.
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// CassetteMatcher reports whether a recorded request can answer a live one.
+// The live request has been redacted like the recorded ones.
+type CassetteMatcher func(live, recorded *CassetteRequest) bool
+
+// MatchMethod matches requests with the same method.
+func MatchMethod(live, recorded *CassetteRequest) bool {
+	return strings.EqualFold(live.Method, recorded.Method)
+}
+
+// MatchURL matches requests with the same url.
+func MatchURL(live, recorded *CassetteRequest) bool {
+	return live.URL == recorded.URL
+}
+
+// MatchBody matches requests with the same body.
+func MatchBody(live, recorded *CassetteRequest) bool {
+	return live.Body == recorded.Body
+}
+
+// MatchHeaders matches requests that have the same values for the given
+// headers.
+func MatchHeaders(names ...string) CassetteMatcher {
+	return func(live, recorded *CassetteRequest) bool {
+		for _, name := range names {
+			if strings.Join(live.Headers.Values(name), "\x00") != strings.Join(recorded.Headers.Values(name), "\x00") {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Cassette records the exchanges of CycleTLS.Do to a JSON file and replays
+// them, so tests run without network. It works on whole calls, a redirect
+// chain is one interaction. Set it on CycleTLS.Cassette.
+type Cassette struct {
+	// Matchers decide which recorded interaction answers a request, all of
+	// them must match. NewCassette sets MatchMethod and MatchURL.
+	Matchers []CassetteMatcher
+	// RedactHeaders are request and response headers whose values are
+	// stored as "REDACTED". NewCassette sets Authorization,
+	// Proxy-Authorization and Cookie.
+	RedactHeaders []string
+	// Redact, when set, is called on every interaction before it is stored
+	// and on live requests before they are matched, to scrub secrets from
+	// urls and bodies.
+	Redact func(interaction *Interaction)
+
+	mode CassetteMode
+	path string
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+	saveErr      error
+}
+
+type cassetteFile struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// NewCassette opens the cassette at path. In CassetteReplay mode the file
+// must exist, in CassetteRecord mode it is replaced by the first recorded
+// interaction.
+func NewCassette(path string, mode CassetteMode) (*Cassette, error) {
+	cassette := &Cassette{
+		Matchers:      []CassetteMatcher{MatchMethod, MatchURL},
+		RedactHeaders: []string{"Authorization", "Proxy-Authorization", "Cookie"},
+		mode:          mode,
+		path:          path,
+	}
+	if mode == CassetteRecord {
+		return cassette, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && mode == CassetteReplayOrRecord {
+		return cassette, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("cassette: %s: %w", path, err)
+	}
+	if file.Version != cassetteVersion {
+		return nil, fmt.Errorf("cassette: %s: unsupported version %d", path, file.Version)
+	}
+	cassette.interactions = file.Interactions
+	cassette.used = make([]bool, len(file.Interactions))
+	return cassette, nil
+}
+
+// Interactions returns the recorded interactions.
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	interactions := make([]Interaction, 0, len(c.interactions))
+	for _, interaction := range c.interactions {
+		interactions = append(interactions, *interaction)
+	}
+	return interactions
+}
+
+// Err returns the error of the last write of the cassette file.
+func (c *Cassette) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.saveErr
+}
+
+// do answers a call of client.DoContext from the cassette or records it.
+func (c *Cassette) do(ctx context.Context, client *CycleTLS, URL string, options Options, Method string) (Response, error) {
+	body, err := bufferBody(&options)
+	if err != nil {
+		return Response{}, err
+	}
+	live := c.redact(&Interaction{Request: CassetteRequest{
+		Method:  strings.ToUpper(Method),
+		URL:     URL,
+		Headers: options.Headers.Clone(),
+		Body:    string(body),
+	}})
+
+	if c.mode != CassetteRecord {
+		if interaction := c.match(&live.Request); interaction != nil {
+			return client.replay(URL, interaction, options.DoNotParseResponse)
+		}
+		if c.mode == CassetteReplay {
+			return Response{}, fmt.Errorf("cassette: no recorded interaction for %s %s", live.Request.Method, live.Request.URL)
+		}
+	}
+
+	response, err := client.do(ctx, URL, options, Method)
+	if err != nil {
+		return response, err
+	}
+	bodyBytes := response.Bytes
+	if response.Body != nil {
+		bodyBytes, err = io.ReadAll(response.Body)
+		_ = response.Body.Close()
+		if err != nil {
+			return response, classifyError(err)
+		}
+		response.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	}
+
+	live.Response = CassetteResponse{StatusCode: response.StatusCode, Headers: response.Headers.Clone()}
+	if utf8.Valid(bodyBytes) {
+		live.Response.Body = string(bodyBytes)
+	} else {
+		live.Response.Body = base64.StdEncoding.EncodeToString(bodyBytes)
+		live.Response.BodyEncoding = "base64"
+	}
+	c.record(c.redact(live))
+	return response, nil
+}
+
+// bufferBody reads the body reader of options into memory so that it can be
+// both matched and sent.
+func bufferBody(options *Options) ([]byte, error) {
+	if options.BodyReader == nil {
+		return []byte(options.Body), nil
+	}
+	defer closeBodyReader(options.BodyReader)
+	body, err := io.ReadAll(options.BodyReader)
+	if err != nil {
+		return nil, err
+	}
+	options.BodyReader = bytes.NewReader(body)
+	options.BodyLength = int64(len(body))
+	return body, nil
+}
+
+// redact applies RedactHeaders and Redact to interaction.
+func (c *Cassette) redact(interaction *Interaction) *Interaction {
+	for _, name := range c.RedactHeaders {
+		for _, headers := range []Header{interaction.Request.Headers, interaction.Response.Headers} {
+			if values := headers.Values(name); len(values) > 0 {
+				redacted := make([]string, len(values))
+				for i := range redacted {
+					redacted[i] = redactedValue
+				}
+				headers[http.CanonicalHeaderKey(name)] = redacted
+			}
+		}
+	}
+	if c.Redact != nil {
+		c.Redact(interaction)
+	}
+	return interaction
+}
+
+// match returns the first unused interaction matching request, or the last
+// used one when all matching interactions have been served.
+func (c *Cassette) match(request *CassetteRequest) *Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last := -1
+	for i, interaction := range c.interactions {
+		if !c.matches(request, &interaction.Request) {
+			continue
+		}
+		if !c.used[i] {
+			c.used[i] = true
+			return interaction
+		}
+		last = i
+	}
+	if last >= 0 {
+		return c.interactions[last]
+	}
+	return nil
+}
+
+func (c *Cassette) matches(live, recorded *CassetteRequest) bool {
+	for _, matcher := range c.Matchers {
+		if !matcher(live, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+// record appends interaction and rewrites the cassette file.
+func (c *Cassette) record(interaction *Interaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interactions = append(c.interactions, interaction)
+	c.used = append(c.used, true)
+	c.saveErr = c.save()
+}
+
+// save writes the cassette file atomically, c.mu must be held.
+func (c *Cassette) save() error {
+	data, err := json.MarshalIndent(cassetteFile{Version: cassetteVersion, Interactions: c.interactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// replay builds the response of a recorded interaction, cookies it sets are
+// stored in the jar as if it had been received.
+func (client *CycleTLS) replay(URL string, interaction *Interaction, stream bool) (Response, error) {
+	recorded := interaction.Response
+	body := []byte(recorded.Body)
+	if recorded.BodyEncoding == "base64" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(recorded.Body); err != nil {
+			return Response{}, fmt.Errorf("cassette: invalid body for %s: %w", URL, err)
+		}
+	}
+
+	u, err := url.Parse(URL)
+	if err != nil {
+		return Response{}, err
+	}
+	headers := recorded.Headers.Clone()
+	if headers == nil {
+		headers = Header{}
+	}
+	setCookies := (&http.Response{Header: headers}).Cookies()
+	if client.CookieJar != nil {
+		client.CookieJar.SetCookies(u, setCookies)
+	}
+	var responseCookies []Cookie
+	for _, cookie := range setCookies {
+		responseCookies = append(responseCookies, convertFHTTPCookie(cookie))
+	}
+
+	response := Response{
+		Headers:         headers,
+		StatusCode:      recorded.StatusCode,
+		ResponseCookies: responseCookies,
+	}
+	if client.CookieJar != nil {
+		response.Cookies = client.Cookies(u)
+	}
+	if stream {
+		response.Body = io.NopCloser(bytes.NewReader(body))
+	} else {
+		response.Bytes = body
+		response.Text = string(body)
+	}
+	return response, nil
+}
@@ -2,7 +2,6 @@ package cycletls
 
 import (
 	http "github.com/Danny-Dasilva/fhttp"
-	"github.com/Danny-Dasilva/fhttp/cookiejar"
 
 	"time"
 
@@ -11,23 +10,29 @@ import (
 
 type browser struct {
 	JA3       string
+	JA4       string
 	UserAgent string
+	Browser   string
+
+	HTTP2Settings *HTTP2Settings
 }
 
 var disabledRedirect = func(req *http.Request, via []*http.Request) error {
 	return http.ErrUseLastResponse
 }
 
-func clientBuilder(browser browser, dialer proxy.ContextDialer, timeout int, disableRedirect bool, jar *cookiejar.Jar) http.Client {
-	//if timeout is not set in call default to 15
+func clientBuilder(browser browser, dialer proxy.ContextDialer, timeout int, disableRedirect bool, jar *Jar) http.Client {
+	//if timeout is not set in call default to 15, a negative timeout disables it
 	if timeout == 0 {
 		timeout = 15
 	}
 	client := http.Client{
 		Transport: newRoundTripper(browser, dialer),
-		Timeout:   time.Duration(timeout) * time.Second,
 		Jar:       jar,
 	}
+	if timeout > 0 {
+		client.Timeout = time.Duration(timeout) * time.Second
+	}
 	//if disableRedirect is set to true httpclient will not redirect
 	if disableRedirect {
 		client.CheckRedirect = disabledRedirect
@@ -36,8 +41,8 @@ func clientBuilder(browser browser, dialer proxy.ContextDialer, timeout int, dis
 }
 
 // newClient creates a new http client
-func newClient(browser browser, timeout int, disableRedirect bool, UserAgent string, proxyURL string, jar *cookiejar.Jar) (http.Client, error) {
-	if len(proxyURL) > 0 && len(proxyURL) > 0 {
+func newClient(browser browser, timeout int, disableRedirect bool, UserAgent string, proxyURL string, jar *Jar) (http.Client, error) {
+	if len(proxyURL) > 0 {
 		dialer, err := newConnectDialer(proxyURL, UserAgent)
 		if err != nil {
 			return http.Client{
@@ -13,7 +13,6 @@ import (
 	"io"
 	"net"
 	"net/url"
-	"strconv"
 	"sync"
 )
 
@@ -122,12 +121,12 @@ func (c *connectDialer) DialContext(ctx context.Context, network, address string
 		resp, err := h2clientConn.RoundTrip(req)
 		if err != nil {
 			_ = rawConn.Close()
-			return nil, err
+			return nil, &Error{Kind: ErrProxyConnect, Op: "connect", Err: err}
 		}
 
 		if resp.StatusCode != http.StatusOK {
 			_ = rawConn.Close()
-			return nil, errors.New("Proxy responded with non 200 code: " + resp.Status + "StatusCode:" + strconv.Itoa(resp.StatusCode))
+			return nil, &ProxyConnectError{StatusCode: resp.StatusCode, Status: resp.Status}
 		}
 		return newHTTP2Conn(rawConn, pw, resp.Body), nil
 	}
@@ -137,21 +136,22 @@ func (c *connectDialer) DialContext(ctx context.Context, network, address string
 		req.ProtoMajor = 1
 		req.ProtoMinor = 1
 
-		err := req.Write(rawConn)
-		if err != nil {
-			_ = rawConn.Close()
-			return nil, err
-		}
-
-		resp, err := http.ReadResponse(bufio.NewReader(rawConn), req)
+		var resp *http.Response
+		err := withConnContext(ctx, rawConn, func() (err error) {
+			if err = req.Write(rawConn); err != nil {
+				return err
+			}
+			resp, err = http.ReadResponse(bufio.NewReader(rawConn), req)
+			return err
+		})
 		if err != nil {
 			_ = rawConn.Close()
-			return nil, err
+			return nil, &Error{Kind: ErrProxyConnect, Op: "connect", Err: err}
 		}
 
 		if resp.StatusCode != http.StatusOK {
 			_ = rawConn.Close()
-			return nil, errors.New("Proxy responded with non 200 code: " + resp.Status + " StatusCode:" + strconv.Itoa(resp.StatusCode))
+			return nil, &ProxyConnectError{StatusCode: resp.StatusCode, Status: resp.Status}
 		}
 		return rawConn, nil
 	}
@@ -184,13 +184,13 @@ func (c *connectDialer) DialContext(ctx context.Context, network, address string
 	case "http":
 		rawConn, err = c.Dialer.DialContext(ctx, network, c.ProxyURL.Host)
 		if err != nil {
-			return nil, err
+			return nil, &Error{Kind: ErrProxyConnect, Op: "dial", Err: err}
 		}
 	case "https":
 		if c.DialTLS != nil {
 			rawConn, negotiatedProtocol, err = c.DialTLS(network, c.ProxyURL.Host)
 			if err != nil {
-				return nil, err
+				return nil, &Error{Kind: ErrProxyConnect, Op: "dial", Err: err}
 			}
 		} else {
 			tlsConf := tls.Config{
@@ -198,14 +198,12 @@ func (c *connectDialer) DialContext(ctx context.Context, network, address string
 				ServerName:         c.ProxyURL.Hostname(),
 				InsecureSkipVerify: true,
 			}
-			tlsConn, err := tls.Dial(network, c.ProxyURL.Host, &tlsConf)
+			tlsDialer := tls.Dialer{NetDialer: &c.Dialer, Config: &tlsConf}
+			conn, err := tlsDialer.DialContext(ctx, network, c.ProxyURL.Host)
 			if err != nil {
-				return nil, err
-			}
-			err = tlsConn.Handshake()
-			if err != nil {
-				return nil, err
+				return nil, &Error{Kind: ErrProxyConnect, Op: "dial", Err: err}
 			}
+			tlsConn := conn.(*tls.Conn)
 			negotiatedProtocol = tlsConn.ConnectionState().NegotiatedProtocol
 			rawConn = tlsConn
 		}
@@ -224,7 +222,7 @@ func (c *connectDialer) DialContext(ctx context.Context, network, address string
 		h2clientConn, err := t.NewClientConn(rawConn)
 		if err != nil {
 			_ = rawConn.Close()
-			return nil, err
+			return nil, &Error{Kind: ErrProxyConnect, Op: "connect", Err: err}
 		}
 
 		proxyConn, err := connectHTTP2(rawConn, h2clientConn)
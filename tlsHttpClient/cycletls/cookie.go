@@ -1,7 +1,9 @@
 package cycletls
 
 import (
+	"fmt"
 	"github.com/Danny-Dasilva/fhttp"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -64,10 +66,193 @@ func (t *Time) UnmarshalJSON(buf []byte) error {
 	return nil
 }
 
-// ParseDateString takes a string and passes it through Approximate
-// Parses into a time.Time
+// MarshalJSON implements json.Marshaler interface, the time is written as a
+// unix timestamp in seconds and a zero time as null.
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.FormatInt(t.Unix(), 10)), nil
+}
+
+// ParseDateString parses a cookie date with the algorithm of RFC 6265
+// section 5.1.1, which accepts every format in use such as
+// "Mon, 02 Jan 2006 15:04:05 GMT", "Monday, 02-Jan-06 15:04:05 GMT" and
+// "Mon Jan  2 15:04:05 2006". RFC 3339 dates are accepted as well. The
+// result is in UTC.
 func ParseDateString(dt string) (time.Time, error) {
-	const layout = "Mon, 02-Jan-2006 15:04:05 MST"
+	if t, err := time.Parse(time.RFC3339, dt); err == nil {
+		return t.UTC(), nil
+	}
+
+	var (
+		hour, minute, second, day, month, year     int
+		foundTime, foundDay, foundMonth, foundYear bool
+	)
+	tokens := strings.FieldsFunc(dt, isCookieDateDelimiter)
+	for _, token := range tokens {
+		if !foundTime {
+			if h, m, s, ok := parseCookieTime(token); ok {
+				hour, minute, second, foundTime = h, m, s, true
+				continue
+			}
+		}
+		if !foundDay {
+			if d, ok := parseCookieDigits(token, 1, 2); ok {
+				day, foundDay = d, true
+				continue
+			}
+		}
+		if !foundMonth && len(token) >= 3 {
+			if m := strings.Index(cookieMonths, strings.ToLower(token[:3])); m >= 0 && m%3 == 0 {
+				month, foundMonth = m/3+1, true
+				continue
+			}
+		}
+		if !foundYear {
+			if y, ok := parseCookieDigits(token, 2, 4); ok {
+				year, foundYear = y, true
+				continue
+			}
+		}
+	}
+
+	if foundYear && year >= 70 && year <= 99 {
+		year += 1900
+	} else if foundYear && year <= 69 {
+		year += 2000
+	}
+	if !foundTime || !foundDay || !foundMonth || !foundYear ||
+		day < 1 || day > 31 || year < 1601 || hour > 23 || minute > 59 || second > 59 {
+		return time.Time{}, fmt.Errorf("cookie: invalid date %q", dt)
+	}
+	t := time.Date(year, time.Month(month), day, hour, minute, second, 0, time.UTC)
+	if t.Day() != day {
+		// the day does not exist in that month, such as 31 Feb
+		return time.Time{}, fmt.Errorf("cookie: invalid date %q", dt)
+	}
+	return t, nil
+}
+
+const cookieMonths = "janfebmaraprmayjunjulaugsepoctnovdec"
+
+// isCookieDateDelimiter reports whether c is a delimiter of RFC 6265
+// section 5.1.1.
+func isCookieDateDelimiter(c rune) bool {
+	return c == 0x09 || (c >= 0x20 && c <= 0x2F) || (c >= 0x3B && c <= 0x40) ||
+		(c >= 0x5B && c <= 0x60) || (c >= 0x7B && c <= 0x7E)
+}
+
+// parseCookieDigits parses the min to max leading digits of token, they may
+// only be followed by a non-digit.
+func parseCookieDigits(token string, min, max int) (int, bool) {
+	n := 0
+	for n < len(token) && token[n] >= '0' && token[n] <= '9' {
+		n++
+	}
+	if n < min || n > max {
+		return 0, false
+	}
+	value, _ := strconv.Atoi(token[:n])
+	return value, true
+}
+
+// parseCookieTime parses the hms-time production, "h:m:s" with one or two
+// digits each, optionally followed by a non-digit.
+func parseCookieTime(token string) (hour, minute, second int, ok bool) {
+	parts := strings.SplitN(token, ":", 3)
+	if len(parts) != 3 {
+		return 0, 0, 0, false
+	}
+	values := make([]int, 3)
+	for i, part := range parts {
+		n := 0
+		for n < len(part) && part[n] >= '0' && part[n] <= '9' {
+			n++
+		}
+		// only the seconds may be followed by other characters
+		if n < 1 || n > 2 || (i < 2 && n != len(part)) {
+			return 0, 0, 0, false
+		}
+		values[i], _ = strconv.Atoi(part[:n])
+	}
+	return values[0], values[1], values[2], true
+}
+
+// convertFHTTPCookie converts an fhttp cookie into a Cookie, an expiry fhttp
+// could not parse is parsed with ParseDateString.
+func convertFHTTPCookie(c *http.Cookie) Cookie {
+	expires := c.Expires
+	if expires.IsZero() && c.RawExpires != "" {
+		expires, _ = ParseDateString(c.RawExpires)
+	}
+	return Cookie{
+		Name:    c.Name,
+		Value:   c.Value,
+		Path:    c.Path,
+		Domain:  c.Domain,
+		Expires: expires,
+		JSONExpires: Time{
+			Time: expires,
+		},
+		RawExpires: c.RawExpires,
+		MaxAge:     c.MaxAge,
+		Secure:     c.Secure,
+		HTTPOnly:   c.HttpOnly,
+		SameSite:   c.SameSite,
+		Raw:        c.Raw,
+		Unparsed:   c.Unparsed,
+	}
+}
+
+// toFHTTP converts the cookie into an fhttp cookie, JSONExpires is used when
+// Expires is not set.
+func (c Cookie) toFHTTP() *http.Cookie {
+	expires := c.Expires
+	if expires.IsZero() {
+		expires = c.JSONExpires.Time
+	}
+	return &http.Cookie{
+		Name:       c.Name,
+		Value:      c.Value,
+		Path:       c.Path,
+		Domain:     c.Domain,
+		Expires:    expires,
+		RawExpires: c.RawExpires,
+		MaxAge:     c.MaxAge,
+		Secure:     c.Secure,
+		HttpOnly:   c.HTTPOnly,
+		SameSite:   c.SameSite,
+		Raw:        c.Raw,
+		Unparsed:   c.Unparsed,
+	}
+}
+
+// SetCookies stores cookies in the jar as if u had set them, the error says
+// why cookies were rejected, see Jar.Set.
+func (client *CycleTLS) SetCookies(u *url.URL, cookies []Cookie) error {
+	httpCookies := make([]*http.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		httpCookies = append(httpCookies, cookie.toFHTTP())
+	}
+	return client.CookieJar.Set(u, httpCookies)
+}
+
+// Cookies returns the cookies the jar sends to u.
+func (client *CycleTLS) Cookies(u *url.URL) []Cookie {
+	var cookies []Cookie
+	for _, cookie := range client.CookieJar.Cookies(u) {
+		cookies = append(cookies, convertFHTTPCookie(cookie))
+	}
+	return cookies
+}
+
+// DeleteCookie removes the cookies called name that the jar sends to u.
+func (client *CycleTLS) DeleteCookie(u *url.URL, name string) {
+	client.CookieJar.Delete(u, name)
+}
 
-	return time.Parse(layout, dt)
+// ClearCookies removes every cookie from the jar.
+func (client *CycleTLS) ClearCookies() {
+	client.CookieJar.Clear()
 }
@@ -0,0 +1,223 @@
+package cycletls
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CookieFormat is a file format a Jar can be saved in.
+type CookieFormat int
+
+const (
+	// CookieFormatNetscape is the cookies.txt format of curl, wget and the
+	// cookie export browser extensions.
+	CookieFormatNetscape CookieFormat = iota
+	// CookieFormatJSON is a JSON array of Cookie.
+	CookieFormatJSON
+)
+
+const (
+	netscapeHeader         = "# Netscape HTTP Cookie File\n"
+	netscapeHttpOnlyPrefix = "#HttpOnly_"
+)
+
+// WriteNetscape writes every cookie of the jar to w in the Netscape
+// cookies.txt format. Session cookies are written with an expiry of 0.
+func (j *Jar) WriteNetscape(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	buf.WriteString(netscapeHeader)
+	buf.WriteString("\n")
+	for _, cookie := range j.All() {
+		domain := cookie.Domain
+		if cookie.HTTPOnly {
+			domain = netscapeHttpOnlyPrefix + domain
+		}
+		var expires int64
+		if !cookie.Expires.IsZero() {
+			expires = cookie.Expires.Unix()
+		}
+		fmt.Fprintf(buf, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain,
+			netscapeBool(strings.HasPrefix(cookie.Domain, ".")),
+			cookie.Path,
+			netscapeBool(cookie.Secure),
+			expires,
+			cookie.Name,
+			cookie.Value,
+		)
+	}
+	return buf.Flush()
+}
+
+func netscapeBool(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// ReadNetscape adds the cookies of a Netscape cookies.txt file to the jar,
+// see Add. Malformed lines are reported with their line number in the error.
+func (j *Jar) ReadNetscape(r io.Reader) error {
+	cookies, err := parseNetscape(r)
+	if err != nil {
+		return err
+	}
+	return j.Add(cookies...)
+}
+
+func parseNetscape(r io.Reader) ([]Cookie, error) {
+	var cookies []Cookie
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(text, netscapeHttpOnlyPrefix)
+		if httpOnly {
+			text = strings.TrimPrefix(text, netscapeHttpOnlyPrefix)
+		} else if strings.HasPrefix(text, "#") || strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) == 6 {
+			// an empty value is sometimes written without its tab
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("cookies.txt line %d: expected 7 tab separated fields, got %d", line, len(fields))
+		}
+
+		expires, err := strconv.ParseFloat(fields[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("cookies.txt line %d: invalid expiry %q", line, fields[4])
+		}
+
+		domain := strings.TrimPrefix(fields[0], ".")
+		if strings.EqualFold(fields[1], "TRUE") {
+			domain = "." + domain
+		}
+		cookie := Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Domain:   domain,
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HTTPOnly: httpOnly,
+		}
+		if expires > 0 {
+			cookie.Expires = time.Unix(int64(expires), 0)
+			cookie.JSONExpires = Time{Time: cookie.Expires}
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies, scanner.Err()
+}
+
+// WriteJSON writes every cookie of the jar to w as a JSON array of Cookie.
+func (j *Jar) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(j.All())
+}
+
+// ReadJSON adds the cookies of a JSON array of Cookie to the jar, see Add.
+func (j *Jar) ReadJSON(r io.Reader) error {
+	var cookies []Cookie
+	if err := json.NewDecoder(r).Decode(&cookies); err != nil {
+		return err
+	}
+	return j.Add(cookies...)
+}
+
+// NewFileJar returns a jar backed by the file at path, cookies already in the
+// file are loaded and the jar writes itself back after every change. Files
+// ending in .json use CookieFormatJSON, anything else CookieFormatNetscape.
+// A missing file is created on the first change.
+func NewFileJar(path string) (*Jar, error) {
+	format := CookieFormatNetscape
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = CookieFormatJSON
+	}
+
+	jar := NewJar()
+	file, err := os.Open(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		defer file.Close()
+		if format == CookieFormatJSON {
+			err = jar.ReadJSON(file)
+		} else {
+			err = jar.ReadNetscape(file)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cookiejar: loading %s: %w", path, err)
+		}
+	}
+
+	jar.file = path
+	jar.format = format
+	return jar, nil
+}
+
+// Save writes the jar to its file, it does nothing for a jar not made by
+// NewFileJar. The file is replaced atomically.
+func (j *Jar) Save() error {
+	if j.file == "" {
+		return nil
+	}
+	j.saveMu.Lock()
+	defer j.saveMu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.file), filepath.Base(j.file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if j.format == CookieFormatJSON {
+		err = j.WriteJSON(tmp)
+	} else {
+		err = j.WriteNetscape(tmp)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o600)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), j.file)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Err returns the error of the last automatic save, nil when it succeeded.
+func (j *Jar) Err() error {
+	j.saveMu.Lock()
+	defer j.saveMu.Unlock()
+	return j.saveErr
+}
+
+// changed saves a file backed jar after a change.
+func (j *Jar) changed() {
+	if j.file == "" {
+		return
+	}
+	err := j.Save()
+	j.saveMu.Lock()
+	j.saveErr = err
+	j.saveMu.Unlock()
+}
@@ -0,0 +1,757 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE-go file.
+
+// This file is derived from net/http/cookiejar of the Go standard library,
+// changed to implement fhttp's http.CookieJar and to list, import and save
+// its cookies.
+
+package cycletls
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	http "github.com/Danny-Dasilva/fhttp"
+	"golang.org/x/net/idna"
+)
+
+// Jar is an in-memory RFC 6265 cookie jar. It implements fhttp's
+// http.CookieJar like cookiejar.Jar, and its cookies can also be listed,
+// imported and saved, see All, Add and NewFileJar.
+//
+// Cookies for a public suffix such as "co.uk" are rejected, Secure cookies
+// and the __Secure- and __Host- prefixes are only accepted from https urls,
+// and SameSite=None requires Secure. Cookies ignores SameSite, CookiesFrom
+// applies it to requests made from another site.
+type Jar struct {
+	psList PublicSuffixList
+
+	// mu locks the remaining fields.
+	mu sync.Mutex
+
+	// entries is a set of entries, keyed by their eTLD+1 and subkeyed by
+	// their name/domain/path.
+	entries map[string]map[string]entry
+
+	// nextSeqNum is the next sequence number assigned to a new cookie.
+	nextSeqNum uint64
+
+	// file is where the jar saves itself after every change, see NewFileJar.
+	file    string
+	format  CookieFormat
+	saveMu  sync.Mutex
+	saveErr error
+}
+
+// NewJar returns an empty cookie jar using DefaultSuffixList.
+func NewJar() *Jar {
+	return &Jar{
+		psList:  DefaultSuffixList(),
+		entries: make(map[string]map[string]entry),
+	}
+}
+
+// entry is the internal representation of a cookie.
+type entry struct {
+	Name       string
+	Value      string
+	Domain     string
+	Path       string
+	SameSite   http.SameSite
+	Secure     bool
+	HttpOnly   bool
+	Persistent bool
+	HostOnly   bool
+	Expires    time.Time
+	Creation   time.Time
+	LastAccess time.Time
+
+	// seqNum keeps the order of cookies with equal Path length and Creation
+	// time deterministic.
+	seqNum uint64
+}
+
+// id returns the domain;path;name triple of e as an id.
+func (e *entry) id() string {
+	return e.Domain + ";" + e.Path + ";" + e.Name
+}
+
+// shouldSend determines whether e's cookie qualifies to be included in a
+// request to host/path. It is the caller's responsibility to check if the
+// cookie is expired.
+func (e *entry) shouldSend(https bool, host, path string) bool {
+	return e.domainMatch(host) && e.pathMatch(path) && (https || !e.Secure)
+}
+
+// domainMatch implements "domain-match" of RFC 6265 section 5.1.3.
+func (e *entry) domainMatch(host string) bool {
+	if e.Domain == host {
+		return true
+	}
+	return !e.HostOnly && hasDotSuffix(host, e.Domain)
+}
+
+// pathMatch implements "path-match" of RFC 6265 section 5.1.4.
+func (e *entry) pathMatch(requestPath string) bool {
+	if requestPath == e.Path {
+		return true
+	}
+	if strings.HasPrefix(requestPath, e.Path) {
+		if e.Path[len(e.Path)-1] == '/' {
+			return true // "/any/" matches "/any/path"
+		} else if requestPath[len(e.Path)] == '/' {
+			return true // "/any" matches "/any/path"
+		}
+	}
+	return false
+}
+
+// cookie returns e as a Cookie, the domain of a domain cookie starts with a
+// dot so that it can be told apart from a host-only cookie.
+func (e *entry) cookie() Cookie {
+	cookie := Cookie{
+		Name:     e.Name,
+		Value:    e.Value,
+		Path:     e.Path,
+		Domain:   e.Domain,
+		Secure:   e.Secure,
+		HTTPOnly: e.HttpOnly,
+		SameSite: e.SameSite,
+	}
+	if !e.HostOnly {
+		cookie.Domain = "." + e.Domain
+	}
+	if e.Persistent {
+		cookie.Expires = e.Expires
+		cookie.JSONExpires = Time{Time: e.Expires}
+	}
+	return cookie
+}
+
+// hasDotSuffix reports whether s ends in "."+suffix.
+func hasDotSuffix(s, suffix string) bool {
+	return len(s) > len(suffix) && s[len(s)-len(suffix)-1] == '.' && s[len(s)-len(suffix):] == suffix
+}
+
+// Cookies implements the Cookies method of the http.CookieJar interface, only
+// Name and Value of the returned cookies are set.
+//
+// It returns an empty slice if the URL's scheme is not HTTP or HTTPS.
+func (j *Jar) Cookies(u *url.URL) (cookies []*http.Cookie) {
+	for _, e := range j.selected(u, time.Now()) {
+		cookies = append(cookies, &http.Cookie{Name: e.Name, Value: e.Value})
+	}
+	return cookies
+}
+
+// CookiesFrom is like Cookies for a request made from site, the url of the
+// page the request comes from. When site is not the same site as u,
+// SameSite=Strict cookies are left out and SameSite=Lax cookies are only sent
+// with a top-level navigation using a safe method, RFC 6265bis section 5.8.3.
+// A nil site is the same site as u.
+func (j *Jar) CookiesFrom(u, site *url.URL, method string, navigation bool) (cookies []*http.Cookie) {
+	crossSite := site != nil && !j.sameSite(u, site)
+	lax := navigation && isSafeMethod(method)
+	for _, e := range j.selected(u, time.Now()) {
+		if crossSite && (e.SameSite == http.SameSiteStrictMode || e.SameSite == http.SameSiteLaxMode && !lax) {
+			continue
+		}
+		cookies = append(cookies, &http.Cookie{Name: e.Name, Value: e.Value})
+	}
+	return cookies
+}
+
+// sameSite reports whether a and b have the same scheme and eTLD+1.
+func (j *Jar) sameSite(a, b *url.URL) bool {
+	if a.Scheme != b.Scheme {
+		return false
+	}
+	hostA, err := canonicalHost(a.Host)
+	if err != nil {
+		return false
+	}
+	hostB, err := canonicalHost(b.Host)
+	if err != nil {
+		return false
+	}
+	return jarKey(hostA, j.psList) == jarKey(hostB, j.psList)
+}
+
+// isSafeMethod reports whether method is safe in the sense of RFC 7231
+// section 4.2.1.
+func isSafeMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// requestJar is the http.CookieJar of a single request, it knows where the
+// request comes from so that SameSite can be applied.
+type requestJar struct {
+	*Jar
+	site       *url.URL
+	method     string
+	navigation bool
+}
+
+// forRequest returns the jar to use for req. The site the request comes from
+// is taken from its Origin or else its Referer header, and it is a navigation
+// unless Sec-Fetch-Mode says otherwise.
+func (j *Jar) forRequest(req *http.Request) http.CookieJar {
+	rj := requestJar{Jar: j, method: req.Method, navigation: true}
+	for _, name := range []string{"Origin", "Referer"} {
+		if v := req.Header.Get(name); v != "" && v != "null" {
+			if site, err := url.Parse(v); err == nil && site.Host != "" {
+				rj.site = site
+				break
+			}
+		}
+	}
+	if mode := req.Header.Get("Sec-Fetch-Mode"); mode != "" {
+		rj.navigation = strings.EqualFold(mode, "navigate")
+	}
+	return rj
+}
+
+// Cookies implements the Cookies method of the http.CookieJar interface with
+// the SameSite rules of CookiesFrom.
+func (rj requestJar) Cookies(u *url.URL) []*http.Cookie {
+	return rj.Jar.CookiesFrom(u, rj.site, rj.method, rj.navigation)
+}
+
+// selected returns the entries to send to u, ordered according to RFC 6265
+// section 5.4 point 2: by longest path and then by earliest creation time.
+func (j *Jar) selected(u *url.URL, now time.Time) []entry {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil
+	}
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return nil
+	}
+	key := jarKey(host, j.psList)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	submap := j.entries[key]
+	if submap == nil {
+		return nil
+	}
+
+	https := u.Scheme == "https"
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	var selected []entry
+	for id, e := range submap {
+		if e.Persistent && !e.Expires.After(now) {
+			delete(submap, id)
+			continue
+		}
+		if !e.shouldSend(https, host, path) {
+			continue
+		}
+		e.LastAccess = now
+		submap[id] = e
+		selected = append(selected, e)
+	}
+	if len(submap) == 0 {
+		delete(j.entries, key)
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		s := selected
+		if len(s[i].Path) != len(s[j].Path) {
+			return len(s[i].Path) > len(s[j].Path)
+		}
+		if !s[i].Creation.Equal(s[j].Creation) {
+			return s[i].Creation.Before(s[j].Creation)
+		}
+		return s[i].seqNum < s[j].seqNum
+	})
+	return selected
+}
+
+// SetCookies implements the SetCookies method of the http.CookieJar interface.
+//
+// It does nothing if the URL's scheme is not HTTP or HTTPS.
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	_ = j.Set(u, cookies)
+}
+
+// Set is like SetCookies but returns an error naming the reasons cookies
+// were rejected, for example a Domain that is a public suffix or a Secure
+// cookie set from an http url.
+func (j *Jar) Set(u *url.URL, cookies []*http.Cookie) error {
+	modified, err := j.setCookies(u, cookies, time.Now())
+	if modified {
+		j.changed()
+	}
+	return err
+}
+
+// setCookies is like Set but takes the current time as parameter, it also
+// reports whether the jar was modified.
+func (j *Jar) setCookies(u *url.URL, cookies []*http.Cookie, now time.Time) (bool, error) {
+	if len(cookies) == 0 {
+		return false, nil
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false, errNotHTTP
+	}
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return false, err
+	}
+	key := jarKey(host, j.psList)
+	defPath := defaultPath(u.Path)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	https := u.Scheme == "https"
+	modified := false
+	var errs []string
+	for _, cookie := range cookies {
+		e, remove, err := j.newEntry(cookie, now, defPath, host, https)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if !https && j.shadowsSecure(key, e) {
+			errs = append(errs, errShadowsSecure.Error())
+			continue
+		}
+		if j.store(key, e, remove, now) {
+			modified = true
+		}
+	}
+	if len(errs) > 0 {
+		return modified, errors.New(strings.Join(errs, "; "))
+	}
+	return modified, nil
+}
+
+// shadowsSecure reports whether e, set from an insecure url, would overwrite
+// a Secure cookie, RFC 6265bis section 5.7 step 16. j.mu must be held.
+func (j *Jar) shadowsSecure(key string, e entry) bool {
+	for _, old := range j.entries[key] {
+		if old.Secure && old.Name == e.Name &&
+			(old.domainMatch(e.Domain) || e.domainMatch(old.Domain)) && old.pathMatch(e.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// store adds e to the jar or removes the entry with its id, it reports
+// whether the jar was modified. j.mu must be held.
+func (j *Jar) store(key string, e entry, remove bool, now time.Time) bool {
+	submap := j.entries[key]
+	id := e.id()
+	if remove {
+		if _, ok := submap[id]; !ok {
+			return false
+		}
+		delete(submap, id)
+		if len(submap) == 0 {
+			delete(j.entries, key)
+		}
+		return true
+	}
+	if submap == nil {
+		submap = make(map[string]entry)
+		j.entries[key] = submap
+	}
+	if old, ok := submap[id]; ok {
+		e.Creation = old.Creation
+		e.seqNum = old.seqNum
+	} else {
+		e.Creation = now
+		e.seqNum = j.nextSeqNum
+		j.nextSeqNum++
+	}
+	e.LastAccess = now
+	submap[id] = e
+	return true
+}
+
+// All returns every cookie in the jar ordered by domain, path and name.
+// Expired cookies are left out and session cookies have a zero Expires. The
+// domain of a domain cookie starts with a dot, a host-only cookie has none.
+func (j *Jar) All() []Cookie {
+	now := time.Now()
+
+	j.mu.Lock()
+	var entries []entry
+	for _, submap := range j.entries {
+		for _, e := range submap {
+			if e.Persistent && !e.Expires.After(now) {
+				continue
+			}
+			entries = append(entries, e)
+		}
+	}
+	j.mu.Unlock()
+
+	sort.Slice(entries, func(a, b int) bool {
+		if entries[a].Domain != entries[b].Domain {
+			return entries[a].Domain < entries[b].Domain
+		}
+		if entries[a].Path != entries[b].Path {
+			return entries[a].Path < entries[b].Path
+		}
+		return entries[a].Name < entries[b].Name
+	})
+	cookies := make([]Cookie, 0, len(entries))
+	for i := range entries {
+		cookies = append(cookies, entries[i].cookie())
+	}
+	return cookies
+}
+
+// Add stores cookies as they are, as exported by All: a Domain starting with
+// a dot makes a domain cookie, any other Domain a host-only cookie, and an
+// empty Path is "/". Expired cookies replace and so delete a stored one.
+// Cookies without a Domain are skipped and reported in the error.
+func (j *Jar) Add(cookies ...Cookie) error {
+	now := time.Now()
+	var errs []string
+
+	j.mu.Lock()
+	modified := false
+	for _, cookie := range cookies {
+		e, remove, err := j.importEntry(cookie, now)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if j.store(jarKey(e.Domain, j.psList), e, remove, now) {
+			modified = true
+		}
+	}
+	j.mu.Unlock()
+
+	if modified {
+		j.changed()
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// importEntry creates an entry from a cookie exported by All.
+func (j *Jar) importEntry(c Cookie, now time.Time) (e entry, remove bool, err error) {
+	domain := strings.ToLower(c.Domain)
+	e.HostOnly = !strings.HasPrefix(domain, ".")
+	e.Domain, err = canonicalHost(strings.TrimPrefix(domain, "."))
+	if err != nil || e.Domain == "" {
+		return e, false, errors.New("cookiejar: cookie " + c.Name + " has no valid domain")
+	}
+	if !e.HostOnly && j.psList != nil && j.psList.PublicSuffix(e.Domain) == e.Domain {
+		return e, false, errors.New("cookiejar: cookie " + c.Name + " is set for the public suffix " + e.Domain)
+	}
+	e.Name = c.Name
+	e.Path = c.Path
+	if e.Path == "" || e.Path[0] != '/' {
+		e.Path = "/"
+	}
+
+	expires := c.Expires
+	if expires.IsZero() {
+		expires = c.JSONExpires.Time
+	}
+	if expires.IsZero() && c.RawExpires != "" {
+		expires, _ = ParseDateString(c.RawExpires)
+	}
+	switch {
+	case c.MaxAge < 0:
+		return e, true, nil
+	case c.MaxAge > 0:
+		e.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		e.Persistent = true
+	case expires.IsZero() || expires.Unix() <= 0:
+		e.Expires = endOfTime
+	case !expires.After(now):
+		return e, true, nil
+	default:
+		e.Expires = expires
+		e.Persistent = true
+	}
+
+	e.Value = c.Value
+	e.Secure = c.Secure
+	e.HttpOnly = c.HTTPOnly
+	e.SameSite = c.SameSite
+	return e, false, nil
+}
+
+// Delete removes the cookies called name that a request to u would send,
+// Secure cookies included.
+func (j *Jar) Delete(u *url.URL, name string) {
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	key := jarKey(host, j.psList)
+
+	j.mu.Lock()
+	modified := false
+	for id, e := range j.entries[key] {
+		if e.Name == name && e.shouldSend(true, host, path) {
+			delete(j.entries[key], id)
+			modified = true
+		}
+	}
+	if len(j.entries[key]) == 0 {
+		delete(j.entries, key)
+	}
+	j.mu.Unlock()
+
+	if modified {
+		j.changed()
+	}
+}
+
+// Clear removes every cookie from the jar.
+func (j *Jar) Clear() {
+	j.mu.Lock()
+	j.entries = make(map[string]map[string]entry)
+	j.mu.Unlock()
+	j.changed()
+}
+
+// canonicalHost strips port from host if present and returns the
+// canonicalized host name.
+func canonicalHost(host string) (string, error) {
+	var err error
+	host = strings.ToLower(host)
+	if hasPort(host) {
+		host, _, err = net.SplitHostPort(host)
+		if err != nil {
+			return "", err
+		}
+	}
+	// strip the trailing dot of fully qualified domain names
+	host = strings.TrimSuffix(host, ".")
+	for i := 0; i < len(host); i++ {
+		if host[i] >= 0x80 {
+			return idna.Lookup.ToASCII(host)
+		}
+	}
+	return host, nil
+}
+
+// hasPort reports whether host contains a port number. host may be a host
+// name, an IPv4 or an IPv6 address.
+func hasPort(host string) bool {
+	colons := strings.Count(host, ":")
+	if colons == 0 {
+		return false
+	}
+	if colons == 1 {
+		return true
+	}
+	return host[0] == '[' && strings.Contains(host, "]:")
+}
+
+// jarKey returns the key to use for a jar, the eTLD+1 of host.
+func jarKey(host string, psl PublicSuffixList) string {
+	if isIP(host) {
+		return host
+	}
+
+	var i int
+	if psl == nil {
+		i = strings.LastIndex(host, ".")
+		if i <= 0 {
+			return host
+		}
+	} else {
+		suffix := psl.PublicSuffix(host)
+		if suffix == host {
+			return host
+		}
+		i = len(host) - len(suffix)
+		if i <= 0 || host[i-1] != '.' {
+			// The public suffix list is broken, storing cookies
+			// under host is a safe stopgap.
+			return host
+		}
+	}
+	prevDot := strings.LastIndex(host[:i-1], ".")
+	return host[prevDot+1:]
+}
+
+// isIP reports whether host is an IP address.
+func isIP(host string) bool {
+	return net.ParseIP(host) != nil
+}
+
+// defaultPath returns the directory part of an URL's path according to
+// RFC 6265 section 5.1.4.
+func defaultPath(path string) string {
+	if len(path) == 0 || path[0] != '/' {
+		return "/" // path is empty or malformed
+	}
+
+	i := strings.LastIndex(path, "/") // path starts with "/", so i != -1
+	if i == 0 {
+		return "/" // path has the form "/abc"
+	}
+	return path[:i] // path is either of form "/abc/xyz" or "/abc/xyz/"
+}
+
+// newEntry creates an entry from a http.Cookie c. now is the current time and
+// is compared to c.Expires to determine deletion of c. defPath and host are the
+// default-path and the canonical host name of the URL c was received from,
+// https whether it is a secure url.
+//
+// remove records whether the jar should delete this cookie, as it has already
+// expired with respect to now. In this case, e may be incomplete, but it will
+// be valid to call e.id (which depends on e's Name, Domain and Path).
+//
+// A malformed c.Domain will result in an error.
+func (j *Jar) newEntry(c *http.Cookie, now time.Time, defPath, host string, https bool) (e entry, remove bool, err error) {
+	e.Name = c.Name
+
+	if c.Secure && !https {
+		return e, false, errInsecureOrigin
+	}
+	if c.SameSite == http.SameSiteNoneMode && !c.Secure {
+		return e, false, errSameSiteNone
+	}
+	// cookie name prefixes, RFC 6265bis section 4.1.3
+	if strings.HasPrefix(c.Name, "__Secure-") && !c.Secure {
+		return e, false, errCookiePrefix
+	}
+	if strings.HasPrefix(c.Name, "__Host-") && (!c.Secure || c.Domain != "" || c.Path != "/") {
+		return e, false, errCookiePrefix
+	}
+
+	if c.Path == "" || c.Path[0] != '/' {
+		e.Path = defPath
+	} else {
+		e.Path = c.Path
+	}
+
+	e.Domain, e.HostOnly, err = j.domainAndType(host, c.Domain)
+	if err != nil {
+		return e, false, err
+	}
+
+	// MaxAge takes precedence over Expires.
+	if c.MaxAge < 0 {
+		return e, true, nil
+	} else if c.MaxAge > 0 {
+		e.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		e.Persistent = true
+	} else {
+		expires := c.Expires
+		if expires.IsZero() && c.RawExpires != "" {
+			// fhttp only parses two layouts, RFC 6265 allows many more
+			expires, _ = ParseDateString(c.RawExpires)
+		}
+		if expires.IsZero() {
+			e.Expires = endOfTime
+			e.Persistent = false
+		} else {
+			if !expires.After(now) {
+				return e, true, nil
+			}
+			e.Expires = expires
+			e.Persistent = true
+		}
+	}
+
+	e.Value = c.Value
+	e.Secure = c.Secure
+	e.HttpOnly = c.HttpOnly
+	e.SameSite = c.SameSite
+
+	return e, false, nil
+}
+
+var (
+	errIllegalDomain   = errors.New("cookiejar: illegal cookie domain attribute")
+	errMalformedDomain = errors.New("cookiejar: malformed cookie domain attribute")
+	errNoHostname      = errors.New("cookiejar: no host name available (IP only)")
+	errInsecureOrigin  = errors.New("cookiejar: secure cookie set from an insecure url")
+	errSameSiteNone    = errors.New("cookiejar: SameSite=None cookie without Secure")
+	errCookiePrefix    = errors.New("cookiejar: cookie does not meet the requirements of its name prefix")
+	errShadowsSecure   = errors.New("cookiejar: cookie from an insecure url would overwrite a secure cookie")
+	errNotHTTP         = errors.New("cookiejar: cookies can only be set from http and https urls")
+)
+
+// endOfTime is the time when session (non-persistent) cookies expire.
+var endOfTime = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+
+// domainAndType determines the cookie's domain and hostOnly attribute.
+func (j *Jar) domainAndType(host, domain string) (string, bool, error) {
+	if domain == "" {
+		// No domain attribute in the SetCookie header indicates a
+		// host cookie.
+		return host, true, nil
+	}
+
+	if isIP(host) {
+		// According to RFC 6265 domain-matching includes not being
+		// an IP address.
+		return "", false, errNoHostname
+	}
+
+	// From here on: If the cookie is valid, it is a domain cookie.
+	// See RFC 6265 section 5.2.3.
+	if domain[0] == '.' {
+		domain = domain[1:]
+	}
+
+	if len(domain) == 0 || domain[0] == '.' {
+		// Received either "Domain=." or "Domain=..some.thing",
+		// both are illegal.
+		return "", false, errMalformedDomain
+	}
+	domain = strings.ToLower(domain)
+
+	if domain[len(domain)-1] == '.' {
+		// "Domain=www.example.com." is rejected, see RFC 6265
+		// sections 5.1.2 and 5.1.3.
+		return "", false, errMalformedDomain
+	}
+
+	// See RFC 6265 section 5.3 #5.
+	if j.psList != nil {
+		if ps := j.psList.PublicSuffix(domain); ps != "" && !hasDotSuffix(domain, ps) {
+			if host == domain {
+				// This is the one exception in which a cookie
+				// with a domain attribute is a host cookie.
+				return host, true, nil
+			}
+			return "", false, errIllegalDomain
+		}
+	}
+
+	// The domain must domain-match host: www.mycompany.com cannot
+	// set cookies for .ourcompetitors.com.
+	if host != domain && !hasDotSuffix(host, domain) {
+		return "", false, errIllegalDomain
+	}
+
+	return domain, false, nil
+}
@@ -47,3 +47,50 @@ func unZstdData(data []byte) (resData []byte, err error) {
 	enflated, err := io.ReadAll(zr)
 	return enflated, err
 }
+
+type decompressReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (d *decompressReader) Close() error {
+	var err error
+	for _, c := range d.closers {
+		if cErr := c.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}
+	return err
+}
+
+// DecompressReader wraps body with a reader that decompresses it on the fly.
+// Closing the returned reader closes body as well.
+func DecompressReader(body io.ReadCloser, encoding []string) (io.ReadCloser, error) {
+	if len(encoding) == 0 {
+		return body, nil
+	}
+	switch encoding[0] {
+	case "gzip":
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return &decompressReader{Reader: gz, closers: []io.Closer{gz, body}}, nil
+	case "deflate":
+		zr, err := zlib.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return &decompressReader{Reader: zr, closers: []io.Closer{zr, body}}, nil
+	case "br":
+		return &decompressReader{Reader: brotli.NewReader(body), closers: []io.Closer{body}}, nil
+	case "zstd":
+		zr, err := zstd.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return &decompressReader{Reader: zr, closers: []io.Closer{zr.IOReadCloser(), body}}, nil
+	default:
+		return body, nil
+	}
+}
@@ -1,81 +1,102 @@
 package cycletls
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
-	"net/url"
-	"os"
-	"strconv"
-	"strings"
 )
 
-type errorMessage struct {
-	StatusCode int
-	debugger   string
-	ErrorMsg   string
-	Op         string
+var (
+	// ErrTimeout is matched by requests that hit the timeout or a context deadline
+	ErrTimeout = errors.New("cycletls: timeout")
+	// ErrDNS is matched by requests whose host could not be resolved
+	ErrDNS = errors.New("cycletls: dns lookup failed")
+	// ErrProxyConnect is matched by requests the proxy refused or could not tunnel
+	ErrProxyConnect = errors.New("cycletls: proxy connect failed")
+	// ErrTLSHandshake is matched by requests that failed the TLS handshake
+	ErrTLSHandshake = errors.New("cycletls: tls handshake failed")
+	// ErrUnsupportedExtension is matched by JA3 strings using an unknown extension
+	ErrUnsupportedExtension = errors.New("cycletls: unsupported extension")
+	// ErrDecompression is matched by responses whose body could not be decompressed
+	ErrDecompression = errors.New("cycletls: decompression failed")
+)
+
+// Error is a failed request. Kind is one of the Err* values and Err is the
+// underlying cause, errors.Is matches both.
+type Error struct {
+	Kind error
+	Op   string
+	Err  error
 }
 
-func lastString(ss []string) string {
-	return ss[len(ss)-1]
+func (e *Error) Error() string {
+	if e.Op == "" {
+		return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", e.Kind, e.Op, e.Err)
 }
 
-// func createErrorString(err: string) (msg, debugger string) {
-func createErrorString(err error) (msg, debugger string) {
-	msg = fmt.Sprintf("Request returned a Syscall Error: %s", err)
-	debugger = fmt.Sprintf("%#v\n", err)
-	return
+func (e *Error) Unwrap() error {
+	return e.Err
 }
 
-func createErrorMessage(StatusCode int, err error, op string) errorMessage {
-	msg := fmt.Sprintf("Request returned a Syscall Error: %s", err)
-	debugger := fmt.Sprintf("%#v\n", err)
-	return errorMessage{StatusCode: StatusCode, debugger: debugger, ErrorMsg: msg, Op: op}
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
 }
 
-func parseError(err error) (errormessage errorMessage) {
-	var op string
+// ProxyConnectError is returned when the proxy answers CONNECT with a non 200
+// status, it matches ErrProxyConnect.
+type ProxyConnectError struct {
+	StatusCode int
+	Status     string
+}
 
-	httpError := err.Error()
-	status := lastString(strings.Split(httpError, "StatusCode:"))
-	StatusCode, _ := strconv.Atoi(status)
-	if StatusCode != 0 {
-		msg, debugger := createErrorString(err)
-		return errorMessage{StatusCode: StatusCode, debugger: debugger, ErrorMsg: msg}
-	}
-	if uErr, ok := err.(*url.Error); ok {
-		if noErr, ok := uErr.Err.(*net.OpError); ok {
-			op = noErr.Op
-			if SyscallError, ok := noErr.Err.(*os.SyscallError); ok {
-				if noErr.Timeout() {
-					return createErrorMessage(408, SyscallError, op)
-				}
-				return createErrorMessage(401, SyscallError, op)
-			} else if AddrError, ok := noErr.Err.(*net.AddrError); ok {
-				return createErrorMessage(405, AddrError, op)
-			} else if DNSError, ok := noErr.Err.(*net.DNSError); ok {
-				return createErrorMessage(421, DNSError, op)
-			} else {
-				return createErrorMessage(421, noErr, op)
-			}
-		}
-		if uErr.Timeout() {
-			return createErrorMessage(408, uErr, op)
-		}
-	}
-	return
+func (e *ProxyConnectError) Error() string {
+	return "proxy responded with non 200 code: " + e.Status
+}
+
+func (e *ProxyConnectError) Is(target error) bool {
+	return target == ErrProxyConnect
+}
+
+// ExtensionError is returned for a JA3 extension CycleTLS can not build, it
+// matches ErrUnsupportedExtension.
+type ExtensionError struct {
+	Extension string
 }
 
-type errExtensionNotExist struct {
-	Context string
+func (e *ExtensionError) Error() string {
+	return fmt.Sprintf("Extension {{ %s }} is not Supported by CycleTLS please raise an issue", e.Extension)
 }
 
-func (w *errExtensionNotExist) Error() string {
-	return fmt.Sprintf("Extension {{ %s }} is not Supported by CycleTLS please raise an issue", w.Context)
+func (e *ExtensionError) Is(target error) bool {
+	return target == ErrUnsupportedExtension
 }
 
-func raiseExtensionError(info string) *errExtensionNotExist {
-	return &errExtensionNotExist{
-		Context: info,
+func raiseExtensionError(info string) *ExtensionError {
+	return &ExtensionError{
+		Extension: info,
+	}
+}
+
+// classifyError tags err returned by http.Client.Do with the matching Err*
+// kind, errors that are already typed or unknown are returned unchanged.
+func classifyError(err error) error {
+	var typed *Error
+	var proxyErr *ProxyConnectError
+	var extErr *ExtensionError
+	if errors.As(err, &typed) || errors.As(err, &proxyErr) || errors.As(err, &extErr) {
+		return err
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return &Error{Kind: ErrDNS, Op: "lookup", Err: err}
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &Error{Kind: ErrTimeout, Err: err}
 	}
+	return err
 }
@@ -0,0 +1,270 @@
+package cycletls
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"sync"
+	"time"
+
+	http "github.com/Danny-Dasilva/fhttp"
+	"github.com/Danny-Dasilva/fhttp/httptrace"
+)
+
+// maxRecordedBody caps the request body kept in an Exchange.
+const maxRecordedBody = 4 << 20
+
+// HeaderField is a single header line.
+type HeaderField struct {
+	Name  string
+	Value string
+}
+
+// Exchange is one request put on the wire and the response it got, a call
+// that follows redirects makes one per hop. Exchanges are recorded when
+// Options.RecordExchanges is set.
+//
+// Durations are -1 when the phase did not happen or could not be measured,
+// Connect and TLS are only known when the request opened a new connection.
+type Exchange struct {
+	Started time.Time
+	Method  string
+	URL     string
+	// Proxy is the proxy the request went through with its password
+	// redacted, empty for direct requests
+	Proxy string
+
+	// RequestHeaders are the headers in the order they were written, with
+	// the HTTP/2 pseudo headers first
+	RequestHeaders []HeaderField
+	// RequestBody holds up to 4 MiB of the body, RequestBodySize counts it
+	// all
+	RequestBody     []byte
+	RequestBodySize int64
+
+	Proto           string
+	StatusCode      int
+	Status          string
+	ResponseHeaders Header
+	// ResponseBodySize is the size of the body as received, before
+	// decompression, -1 when it was not read by cycletls
+	ResponseBodySize int64
+	// Err is set when no response was received
+	Err error
+
+	Blocked time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	Send    time.Duration
+	Wait    time.Duration
+	Receive time.Duration
+}
+
+// dialTimings receives the duration of a new connection's dial and TLS
+// handshake, it is passed to dialTLS through the request context.
+type dialTimings struct {
+	mu      sync.Mutex
+	connect time.Duration
+	tls     time.Duration
+}
+
+type dialTimingsKey struct{}
+
+// recordDial stores the timings of a new connection when ctx asks for them.
+func recordDial(ctx context.Context, connect, tls time.Duration) {
+	if timings, ok := ctx.Value(dialTimingsKey{}).(*dialTimings); ok {
+		timings.mu.Lock()
+		timings.connect, timings.tls = connect, tls
+		timings.mu.Unlock()
+	}
+}
+
+// recordingTransport records an Exchange for every request that passes
+// through it.
+type recordingTransport struct {
+	next  http.RoundTripper
+	proxy string
+
+	mu        sync.Mutex
+	exchanges []*Exchange
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	exchange := &Exchange{
+		Started:          time.Now(),
+		Method:           req.Method,
+		URL:              req.URL.String(),
+		Proxy:            rt.proxy,
+		ResponseBodySize: -1,
+		Blocked:          -1,
+		Connect:          -1,
+		TLS:              -1,
+		Send:             -1,
+		Wait:             -1,
+		Receive:          -1,
+	}
+	rt.mu.Lock()
+	rt.exchanges = append(rt.exchanges, exchange)
+	rt.mu.Unlock()
+
+	var (
+		mu        sync.Mutex
+		gotConn   time.Time
+		wrote     time.Time
+		firstByte time.Time
+	)
+	timings := &dialTimings{connect: -1, tls: -1}
+	trace := &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) {
+			mu.Lock()
+			gotConn = time.Now()
+			mu.Unlock()
+		},
+		WroteHeaderField: func(key string, values []string) {
+			mu.Lock()
+			for _, value := range values {
+				exchange.RequestHeaders = append(exchange.RequestHeaders, HeaderField{Name: key, Value: value})
+			}
+			mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			mu.Lock()
+			wrote = time.Now()
+			mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			firstByte = time.Now()
+			mu.Unlock()
+		},
+	}
+	ctx := context.WithValue(req.Context(), dialTimingsKey{}, timings)
+	req = req.WithContext(httptrace.WithClientTrace(ctx, trace))
+
+	var body *recordedBody
+	if req.Body != nil && req.Body != http.NoBody {
+		body = &recordedBody{ReadCloser: req.Body}
+		req.Body = body
+	}
+
+	resp, err := rt.next.RoundTrip(req)
+	done := time.Now()
+
+	mu.Lock()
+	defer mu.Unlock()
+	timings.mu.Lock()
+	exchange.Connect, exchange.TLS = timings.connect, timings.tls
+	timings.mu.Unlock()
+	if !gotConn.IsZero() {
+		exchange.Blocked = gotConn.Sub(exchange.Started)
+		for _, d := range []time.Duration{exchange.Connect, exchange.TLS} {
+			if d > 0 {
+				exchange.Blocked -= d
+			}
+		}
+		if exchange.Blocked < 0 {
+			exchange.Blocked = 0
+		}
+		if !wrote.IsZero() {
+			exchange.Send = wrote.Sub(gotConn)
+		}
+	}
+	if !wrote.IsZero() {
+		if firstByte.IsZero() {
+			firstByte = done
+		}
+		exchange.Wait = firstByte.Sub(wrote)
+	}
+	exchange.RequestHeaders = dropTransferEcho(exchange.RequestHeaders)
+	if body != nil {
+		body.mu.Lock()
+		exchange.RequestBody = append([]byte(nil), body.buf.Bytes()...)
+		exchange.RequestBodySize = body.size
+		body.mu.Unlock()
+	}
+
+	if err != nil {
+		exchange.Err = err
+		return resp, err
+	}
+	exchange.Proto = resp.Proto
+	exchange.StatusCode = resp.StatusCode
+	exchange.Status = resp.Status
+	exchange.ResponseHeaders = resp.Header.Clone()
+	return resp, nil
+}
+
+// recorded returns the exchanges so far, the last one is the final hop.
+func (rt *recordingTransport) recorded() []*Exchange {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return append([]*Exchange(nil), rt.exchanges...)
+}
+
+// dropTransferEcho removes the leading Connection, Content-Length and
+// Transfer-Encoding fields that fhttp's HTTP/1 writer reports when it adds
+// them to the header, before it reports them again as it writes them.
+func dropTransferEcho(fields []HeaderField) []HeaderField {
+	n := 0
+	for n < len(fields) {
+		name := http.CanonicalHeaderKey(fields[n].Name)
+		if name != "Connection" && name != "Content-Length" && name != "Transfer-Encoding" {
+			break
+		}
+		n++
+	}
+
+	kept := make([]HeaderField, 0, len(fields))
+	for i, field := range fields {
+		if i < n && containsField(fields[n:], field) {
+			continue
+		}
+		kept = append(kept, field)
+	}
+	return kept
+}
+
+func containsField(fields []HeaderField, field HeaderField) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// redactProxy returns proxyURL with its password masked.
+func redactProxy(proxyURL string) string {
+	if proxyURL == "" {
+		return ""
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
+
+// recordedBody keeps a copy of the start of a request body as it is sent,
+// the transport may still be reading it when RoundTrip returns.
+type recordedBody struct {
+	io.ReadCloser
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	size int64
+}
+
+func (b *recordedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.size += int64(n)
+	if room := maxRecordedBody - b.buf.Len(); room > 0 {
+		if n < room {
+			room = n
+		}
+		b.buf.Write(p[:room])
+	}
+	return n, err
+}
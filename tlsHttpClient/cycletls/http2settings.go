@@ -0,0 +1,258 @@
+package cycletls
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls/internal/http2"
+)
+
+// ErrInvalidHTTP2Settings is matched by HTTP/2 settings CycleTLS can not
+// send.
+var ErrInvalidHTTP2Settings = errors.New("cycletls: invalid http2 settings")
+
+func invalidHTTP2Settings(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidHTTP2Settings, fmt.Sprintf(format, args...))
+}
+
+// HTTP/2 SETTINGS parameters, RFC 7540 section 6.5.2.
+const (
+	HTTP2SettingHeaderTableSize      uint16 = 1
+	HTTP2SettingEnablePush           uint16 = 2
+	HTTP2SettingMaxConcurrentStreams uint16 = 3
+	HTTP2SettingInitialWindowSize    uint16 = 4
+	HTTP2SettingMaxFrameSize         uint16 = 5
+	HTTP2SettingMaxHeaderListSize    uint16 = 6
+)
+
+// HTTP2Setting is one parameter of the first SETTINGS frame.
+type HTTP2Setting struct {
+	ID    uint16
+	Value uint32
+}
+
+// HTTP2Priority is a PRIORITY frame sent before the first request, Weight is
+// the effective weight from 1 to 256, one more than the value on the wire.
+type HTTP2Priority struct {
+	StreamID  uint32
+	Exclusive bool
+	DependsOn uint32
+	Weight    uint16
+}
+
+// HTTP2Settings is what an HTTP/2 connection starts with and what Akamai's
+// passive fingerprint is made of: the SETTINGS frame in the order given, the
+// increment of the connection WINDOW_UPDATE, none when 0, the PRIORITY
+// frames and the pseudo header order of the requests. The first request is
+// sent on the stream after those of the PRIORITY frames, as Firefox does.
+type HTTP2Settings struct {
+	Settings     []HTTP2Setting
+	WindowUpdate uint32
+	Priorities   []HTTP2Priority
+	// PseudoHeaderOrder is used when Options.PHeaderOrder is empty, nil
+	// keeps DefaultPHeaderOrder
+	PseudoHeaderOrder []string
+}
+
+// akamaiPseudoHeaders maps the letters of an Akamai fingerprint to pseudo
+// headers.
+var akamaiPseudoHeaders = map[string]string{
+	"m": ":method",
+	"a": ":authority",
+	"s": ":scheme",
+	"p": ":path",
+}
+
+// ParseHTTP2Settings parses an Akamai HTTP/2 fingerprint such as
+// 1:65536;2:0;4:6291456;6:262144|15663105|0|m,a,s,p. The window update is 0
+// or 00 for none, the priorities 0 for none and otherwise comma separated
+// stream:exclusive:depends on:weight. The pseudo header order may be left
+// out.
+func ParseHTTP2Settings(akamai string) (*HTTP2Settings, error) {
+	parts := strings.Split(strings.TrimSpace(akamai), "|")
+	if len(parts) < 3 || len(parts) > 4 {
+		return nil, invalidHTTP2Settings("want 3 or 4 | separated parts, got %d", len(parts))
+	}
+	s := &HTTP2Settings{}
+
+	if parts[0] != "" {
+		for i, setting := range strings.Split(parts[0], ";") {
+			idValue := strings.Split(setting, ":")
+			if len(idValue) != 2 {
+				return nil, invalidHTTP2Settings("setting[%d] %q is not id:value", i, setting)
+			}
+			id, err := strconv.ParseUint(idValue[0], 10, 16)
+			if err != nil {
+				return nil, invalidHTTP2Settings("setting[%d] id %q is not a number", i, idValue[0])
+			}
+			value, err := strconv.ParseUint(idValue[1], 10, 32)
+			if err != nil {
+				return nil, invalidHTTP2Settings("setting[%d] value %q is not a number", i, idValue[1])
+			}
+			s.Settings = append(s.Settings, HTTP2Setting{ID: uint16(id), Value: uint32(value)})
+		}
+	}
+
+	windowUpdate, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return nil, invalidHTTP2Settings("window update %q is not a number", parts[1])
+	}
+	s.WindowUpdate = uint32(windowUpdate)
+
+	if parts[2] != "0" {
+		for i, priority := range strings.Split(parts[2], ",") {
+			fields := strings.Split(priority, ":")
+			if len(fields) != 4 {
+				return nil, invalidHTTP2Settings("priority[%d] %q is not stream:exclusive:depends on:weight", i, priority)
+			}
+			var values [4]uint64
+			for j, field := range fields {
+				if values[j], err = strconv.ParseUint(field, 10, 32); err != nil {
+					return nil, invalidHTTP2Settings("priority[%d] %q is not a number", i, field)
+				}
+			}
+			if values[1] > 1 {
+				return nil, invalidHTTP2Settings("priority[%d] exclusive %d is neither 0 nor 1", i, values[1])
+			}
+			if values[3] > 256 {
+				return nil, invalidHTTP2Settings("priority[%d] weight %d is above 256", i, values[3])
+			}
+			s.Priorities = append(s.Priorities, HTTP2Priority{
+				StreamID:  uint32(values[0]),
+				Exclusive: values[1] == 1,
+				DependsOn: uint32(values[2]),
+				Weight:    uint16(values[3]),
+			})
+		}
+	}
+
+	if len(parts) == 4 {
+		for _, letter := range strings.Split(parts[3], ",") {
+			name, ok := akamaiPseudoHeaders[letter]
+			if !ok {
+				return nil, invalidHTTP2Settings("pseudo header %q is none of m, a, s and p", letter)
+			}
+			s.PseudoHeaderOrder = append(s.PseudoHeaderOrder, name)
+		}
+	}
+
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// String returns the Akamai fingerprint of s, the pseudo header order is
+// left out when s has none.
+func (s *HTTP2Settings) String() string {
+	settings := make([]string, len(s.Settings))
+	for i, setting := range s.Settings {
+		settings[i] = fmt.Sprintf("%d:%d", setting.ID, setting.Value)
+	}
+
+	windowUpdate := "00"
+	if s.WindowUpdate != 0 {
+		windowUpdate = strconv.FormatUint(uint64(s.WindowUpdate), 10)
+	}
+
+	priorities := "0"
+	if len(s.Priorities) > 0 {
+		parts := make([]string, len(s.Priorities))
+		for i, p := range s.Priorities {
+			exclusive := 0
+			if p.Exclusive {
+				exclusive = 1
+			}
+			parts[i] = fmt.Sprintf("%d:%d:%d:%d", p.StreamID, exclusive, p.DependsOn, p.Weight)
+		}
+		priorities = strings.Join(parts, ",")
+	}
+
+	fingerprint := []string{strings.Join(settings, ";"), windowUpdate, priorities}
+	if len(s.PseudoHeaderOrder) > 0 {
+		pseudo := make([]string, len(s.PseudoHeaderOrder))
+		for i, name := range s.PseudoHeaderOrder {
+			pseudo[i] = strings.TrimPrefix(strings.ToLower(name), ":")[:1]
+		}
+		fingerprint = append(fingerprint, strings.Join(pseudo, ","))
+	}
+	return strings.Join(fingerprint, "|")
+}
+
+func (s *HTTP2Settings) validate() error {
+	seen := map[uint16]bool{}
+	for _, setting := range s.Settings {
+		if setting.ID == 0 {
+			return invalidHTTP2Settings("setting id 0 is reserved")
+		}
+		if seen[setting.ID] {
+			return invalidHTTP2Settings("duplicate setting %d", setting.ID)
+		}
+		seen[setting.ID] = true
+		switch setting.ID {
+		case HTTP2SettingEnablePush:
+			if setting.Value > 1 {
+				return invalidHTTP2Settings("enable push %d is neither 0 nor 1", setting.Value)
+			}
+		case HTTP2SettingInitialWindowSize:
+			if setting.Value > maxHTTP2WindowSize {
+				return invalidHTTP2Settings("initial window size %d is above %d", setting.Value, maxHTTP2WindowSize)
+			}
+		case HTTP2SettingMaxFrameSize:
+			if setting.Value < 1<<14 || setting.Value > 1<<24-1 {
+				return invalidHTTP2Settings("max frame size %d is not between %d and %d", setting.Value, 1<<14, 1<<24-1)
+			}
+		}
+	}
+	if s.WindowUpdate > maxHTTP2WindowSize-http2DefaultWindowSize {
+		return invalidHTTP2Settings("window update %d makes the connection window larger than %d", s.WindowUpdate, maxHTTP2WindowSize)
+	}
+	for i, p := range s.Priorities {
+		if p.StreamID == 0 || p.StreamID > maxHTTP2PriorityStream {
+			return invalidHTTP2Settings("priority[%d] stream %d is not between 1 and %d", i, p.StreamID, maxHTTP2PriorityStream)
+		}
+		if p.DependsOn == p.StreamID {
+			return invalidHTTP2Settings("priority[%d] stream %d depends on itself", i, p.StreamID)
+		}
+		if p.Weight == 0 || p.Weight > 256 {
+			return invalidHTTP2Settings("priority[%d] weight %d is not between 1 and 256", i, p.Weight)
+		}
+	}
+	if len(s.PseudoHeaderOrder) > 0 {
+		if _, err := parsePHeaderOrder(s.PseudoHeaderOrder); err != nil {
+			return invalidHTTP2Settings("%v", err)
+		}
+	}
+	return nil
+}
+
+const (
+	http2DefaultWindowSize = 65535
+	maxHTTP2WindowSize     = 1<<31 - 1
+	// maxHTTP2PriorityStream leaves a stream for the first request
+	maxHTTP2PriorityStream = 1<<31 - 3
+)
+
+// preface returns the frames of s for the HTTP/2 transport.
+func (s *HTTP2Settings) preface() *http2.Preface {
+	preface := &http2.Preface{
+		Settings:     make([]http2.Setting, len(s.Settings)),
+		WindowUpdate: s.WindowUpdate,
+	}
+	for i, setting := range s.Settings {
+		preface.Settings[i] = http2.Setting{ID: http2.SettingID(setting.ID), Val: setting.Value}
+	}
+	for _, p := range s.Priorities {
+		preface.Priorities = append(preface.Priorities, http2.PrefacePriority{
+			StreamID: p.StreamID,
+			PriorityParam: http2.PriorityParam{
+				StreamDep: p.DependsOn,
+				Exclusive: p.Exclusive,
+				Weight:    uint8(p.Weight - 1),
+			},
+		})
+	}
+	return preface
+}
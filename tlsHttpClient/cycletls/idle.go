@@ -0,0 +1,69 @@
+package cycletls
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// idleTimeout cancels a streamed request that receives nothing for timeout,
+// it replaces http.Client.Timeout which would also limit reading the body.
+// A nil idleTimeout never fires.
+type idleTimeout struct {
+	timer   *time.Timer
+	timeout time.Duration
+	cancel  context.CancelFunc
+	fired   int32
+}
+
+func newIdleTimeout(timeout time.Duration, cancel context.CancelFunc) *idleTimeout {
+	t := &idleTimeout{timeout: timeout, cancel: cancel}
+	t.timer = time.AfterFunc(timeout, func() {
+		atomic.StoreInt32(&t.fired, 1)
+		cancel()
+	})
+	return t
+}
+
+func (t *idleTimeout) reset() {
+	if t != nil {
+		t.timer.Reset(t.timeout)
+	}
+}
+
+// stop releases the context of the request, its body can not be read
+// afterwards.
+func (t *idleTimeout) stop() {
+	if t != nil {
+		t.timer.Stop()
+		t.cancel()
+	}
+}
+
+// err tags err as ErrTimeout when the timer cancelled the request.
+func (t *idleTimeout) err(err error) error {
+	if err == nil || err == io.EOF || t == nil || atomic.LoadInt32(&t.fired) == 0 {
+		return err
+	}
+	return &Error{Kind: ErrTimeout, Op: fmt.Sprintf("nothing received for %v", t.timeout), Err: err}
+}
+
+// idleBody restarts the idle timeout on every read and stops it on Close.
+type idleBody struct {
+	io.ReadCloser
+	idle *idleTimeout
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.idle.reset()
+	return n, b.idle.err(err)
+}
+
+func (b *idleBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.idle.stop()
+	return err
+}
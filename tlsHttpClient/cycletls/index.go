@@ -1,63 +1,157 @@
 package cycletls
 
 import (
+	"context"
 	http "github.com/Danny-Dasilva/fhttp"
-	"github.com/Danny-Dasilva/fhttp/cookiejar"
 	"io"
 	"log"
 	"net/url"
 	"strings"
+	"time"
 )
 
 // Options sets CycleTLS client options
 type Options struct {
 	URL             string
 	Method          string
-	Headers         map[string]string
+	Headers         Header
 	Body            string
 	Ja3             string
+	Ja4             string // JA4_r fingerprint, takes precedence over Ja3
 	UserAgent       string
+	Browser         string // one of the Browser* families, guessed from UserAgent when empty
 	Proxy           string
 	Timeout         int
 	DisableRedirect bool
 	HeaderOrder     []string
 	OrderAsProvided bool
+	// PHeaderOrder is the HTTP/2 pseudo header order, it must list :method,
+	// :authority, :scheme and :path exactly once
+	PHeaderOrder []string
+	// HTTP2Settings replace the SETTINGS, WINDOW_UPDATE and PRIORITY frames
+	// of the Browser's HTTP/2 preset, see ParseHTTP2Settings
+	HTTP2Settings *HTTP2Settings
+
+	// BodyReader takes precedence over Body and is streamed to the server.
+	// BodyLength is its size, a negative value sends it chunked.
+	BodyReader io.Reader
+	BodyLength int64
+
+	// DoNotParseResponse leaves the body unread, Response.Body streams it
+	// decompressed and must be closed by the caller
+	DoNotParseResponse bool
+
+	// RecordExchanges fills Response.Exchanges with what was put on the
+	// wire, see Exchange
+	RecordExchanges bool
 }
 
+// DefaultHeaderOrder is the Chrome header order used when Options.HeaderOrder
+// is empty. Headers missing from the order are sent after it sorted by name.
+var DefaultHeaderOrder = []string{
+	"host",
+	"connection",
+	"cache-control",
+	"device-memory",
+	"viewport-width",
+	"rtt",
+	"downlink",
+	"ect",
+	"sec-ch-ua",
+	"sec-ch-ua-mobile",
+	"sec-ch-ua-full-version",
+	"sec-ch-ua-arch",
+	"sec-ch-ua-platform",
+	"sec-ch-ua-platform-version",
+	"sec-ch-ua-model",
+	"upgrade-insecure-requests",
+	"user-agent",
+	"accept",
+	"sec-fetch-site",
+	"sec-fetch-mode",
+	"sec-fetch-user",
+	"sec-fetch-dest",
+	"referer",
+	"accept-encoding",
+	"accept-language",
+	"cookie",
+}
+
+// DefaultPHeaderOrder is the Chrome pseudo header order used when
+// Options.PHeaderOrder is empty.
+var DefaultPHeaderOrder = []string{":method", ":authority", ":scheme", ":path"}
+
+// Header holds multi-value headers keyed by their canonical name, it is
+// fhttp's http.Header so Add, Get, Set, Values and Del are available.
+type Header = http.Header
+
 type cycleTLSRequest struct {
 	RequestID string  `json:"requestId"`
 	Options   Options `json:"options"`
-	jar       *cookiejar.Jar
+	jar       *Jar
+	ctx       context.Context
 }
 
 // rename to request+client+options
 type fullRequest struct {
-	req     *http.Request
-	client  http.Client
-	options cycleTLSRequest
+	req      *http.Request
+	client   http.Client
+	options  cycleTLSRequest
+	recorder *recordingTransport
+	idle     *idleTimeout
 }
 
 // Response contains CycleTLS response data
 type Response struct {
-	Headers    map[string]string
+	Headers Header
+	// Cookies is the jar's view of the request URL after the response, it
+	// includes cookies stored by earlier responses
 	Cookies    []Cookie
 	StatusCode int
 	Bytes      []byte
 	Text       string
+	Body       io.ReadCloser
+
+	// ResponseCookies are the cookies set by the Set-Cookie headers of this
+	// response only, with all of their attributes
+	ResponseCookies []Cookie
+
+	// Exchanges are the requests sent and responses received, redirects
+	// included, when Options.RecordExchanges is set. They are also set
+	// when the request fails.
+	Exchanges []*Exchange
 }
 
 // CycleTLS creates full request and response
 type CycleTLS struct {
 	ReqChan   chan fullRequest
 	RespChan  chan Response
-	CookieJar *cookiejar.Jar
+	CookieJar *Jar
+	// Cassette, when set, records or replays the calls of Do and DoContext
+	Cassette *Cassette
 }
 
 // ready Request
-func processRequest(request cycleTLSRequest) (result fullRequest) {
+func processRequest(request cycleTLSRequest) (result fullRequest, err error) {
+	// once the request is sent the transport closes the body, until then
+	// it is ours to close
+	defer func() {
+		if err != nil {
+			closeBodyReader(request.Options.BodyReader)
+		}
+	}()
 	var browser = browser{
 		JA3:       request.Options.Ja3,
+		JA4:       request.Options.Ja4,
+		Browser:   request.Options.Browser,
 		UserAgent: request.Options.UserAgent,
+
+		HTTP2Settings: request.Options.HTTP2Settings,
+	}
+	if browser.HTTP2Settings != nil {
+		if err := browser.HTTP2Settings.validate(); err != nil {
+			return result, err
+		}
 	}
 
 	client, err := newClient(
@@ -69,12 +163,37 @@ func processRequest(request cycleTLSRequest) (result fullRequest) {
 		request.jar,
 	)
 	if err != nil {
-		log.Fatal(err)
+		return result, err
 	}
 
-	req, err := http.NewRequest(strings.ToUpper(request.Options.Method), request.Options.URL, strings.NewReader(request.Options.Body))
+	ctx := request.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// the client timeout would cut off a streamed body that takes longer to
+	// read, an idle timeout limits the headers and every read instead
+	var idle *idleTimeout
+	if request.Options.DoNotParseResponse && client.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		idle = newIdleTimeout(client.Timeout, cancel)
+		client.Timeout = 0
+		defer func() {
+			if err != nil {
+				idle.stop()
+			}
+		}()
+	}
+	var body io.Reader = strings.NewReader(request.Options.Body)
+	if request.Options.BodyReader != nil {
+		body = request.Options.BodyReader
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(request.Options.Method), request.Options.URL, body)
 	if err != nil {
-		log.Fatal(err)
+		return result, err
+	}
+	if request.Options.BodyReader != nil {
+		setContentLength(req, request.Options.BodyLength)
 	}
 	var headerOrder []string
 	//master header order, all your headers will be ordered based on this list and anything extra will be appended to the end
@@ -86,43 +205,47 @@ func processRequest(request cycleTLSRequest) (result fullRequest) {
 			headerOrder = append(headerOrder, lowerCaseKey)
 		}
 	} else {
-		headerOrder = append(headerOrder,
-			"host",
-			"connection",
-			"cache-control",
-			"device-memory",
-			"viewport-width",
-			"rtt",
-			"downlink",
-			"ect",
-			"sec-ch-ua",
-			"sec-ch-ua-mobile",
-			"sec-ch-ua-full-version",
-			"sec-ch-ua-arch",
-			"sec-ch-ua-platform",
-			"sec-ch-ua-platform-version",
-			"sec-ch-ua-model",
-			"upgrade-insecure-requests",
-			"user-agent",
-			"accept",
-			"sec-fetch-site",
-			"sec-fetch-mode",
-			"sec-fetch-user",
-			"sec-fetch-dest",
-			"referer",
-			"accept-encoding",
-			"accept-language",
-			"cookie",
-		)
+		headerOrder = DefaultHeaderOrder
+	}
+
+	pHeaderOrder := DefaultPHeaderOrder
+	if len(request.Options.PHeaderOrder) > 0 {
+		pHeaderOrder, err = parsePHeaderOrder(request.Options.PHeaderOrder)
+		if err != nil {
+			return result, err
+		}
+	} else if browser.HTTP2Settings != nil && len(browser.HTTP2Settings.PseudoHeaderOrder) > 0 {
+		pHeaderOrder, err = parsePHeaderOrder(browser.HTTP2Settings.PseudoHeaderOrder)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	//set our Host header
+	u, err := url.Parse(request.Options.URL)
+	if err != nil {
+		return result, err
+	}
+
+	//append our normal headers
+	req.Header = http.Header{}
+	for k, values := range request.Options.Headers {
+		if http.CanonicalHeaderKey(k) == "Content-Length" {
+			continue
+		}
+		req.Header.Del(k)
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
 	}
+	req.Header.Set("Host", u.Host)
+	req.Header.Set("user-agent", request.Options.UserAgent)
 
-	headerMap := make(map[string]string)
 	var headerOrderKey []string
 	for _, key := range headerOrder {
-		for k, v := range request.Options.Headers {
+		for k := range req.Header {
 			lowerCaseKey := strings.ToLower(k)
 			if key == lowerCaseKey {
-				headerMap[k] = v
 				headerOrderKey = append(headerOrderKey, lowerCaseKey)
 			}
 		}
@@ -130,84 +253,97 @@ func processRequest(request cycleTLSRequest) (result fullRequest) {
 	}
 
 	//ordering the pseudo headers and our normal headers
-	req.Header = http.Header{
-		http.HeaderOrderKey:  headerOrderKey,
-		http.PHeaderOrderKey: {":method", ":authority", ":scheme", ":path"},
-	}
-	//set our Host header
-	u, err := url.Parse(request.Options.URL)
-	if err != nil {
-		panic(err)
+	req.Header[http.HeaderOrderKey] = headerOrderKey
+	req.Header[http.PHeaderOrderKey] = pHeaderOrder
+
+	if request.jar != nil {
+		client.Jar = request.jar.forRequest(req)
 	}
 
-	//append our normal headers
-	for k, v := range request.Options.Headers {
-		if k != "Content-Length" {
-			req.Header.Set(k, v)
-		}
+	var recorder *recordingTransport
+	if request.Options.RecordExchanges {
+		recorder = &recordingTransport{next: client.Transport, proxy: redactProxy(request.Options.Proxy)}
+		client.Transport = recorder
 	}
-	req.Header.Set("Host", u.Host)
-	req.Header.Set("user-agent", request.Options.UserAgent)
-	return fullRequest{req: req, client: client, options: request}
+	return fullRequest{req: req, client: client, options: request, recorder: recorder, idle: idle}, nil
 
 }
 
+// closeBodyReader closes reader when it is an io.Closer, such as the file of
+// a body that is not sent.
+func closeBodyReader(reader io.Reader) {
+	if closer, ok := reader.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
 func dispatcher(res fullRequest) (response Response, err error) {
 	resp, err := res.client.Do(res.req)
-	if resp != nil && resp.Body != nil {
+	err = res.idle.err(err)
+	streamBody := res.options.Options.DoNotParseResponse
+	if err != nil || !streamBody {
+		defer res.idle.stop()
+	} else {
+		res.idle.reset()
+	}
+	if resp != nil && resp.Body != nil && (err != nil || !streamBody) {
 		defer resp.Body.Close()
 	}
+	var exchanges []*Exchange
+	if res.recorder != nil {
+		exchanges = res.recorder.recorded()
+	}
 	if err != nil {
-		parsedError := parseError(err)
-		response := parsedError.ErrorMsg + "-> \n" + err.Error()
 		return Response{
-			Headers:    map[string]string{},
-			Cookies:    []Cookie{},
-			StatusCode: parsedError.StatusCode,
-			Bytes:      []byte(response),
-			Text:       response,
-		}, err
+			Headers:         Header{},
+			Cookies:         []Cookie{},
+			ResponseCookies: []Cookie{},
+			Exchanges:       exchanges,
+		}, classifyError(err)
 	}
 
 	encoding := resp.Header["Content-Encoding"]
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return response, err
-	}
-
-	text, bytes := DecompressBody(bodyBytes, encoding)
-	headers := make(map[string]string)
-
-	for name, values := range resp.Header {
-		if name == "Set-Cookie" {
-			headers[name] = strings.Join(values, "/,/")
-		} else {
-			for _, value := range values {
-				headers[name] = value
-			}
+	var (
+		text  string
+		bytes []byte
+		body  io.ReadCloser
+	)
+	if streamBody {
+		body, err = DecompressReader(resp.Body, encoding)
+		if err != nil {
+			_ = resp.Body.Close()
+			res.idle.stop()
+			return response, &Error{Kind: ErrDecompression, Op: encoding[0], Err: err}
+		}
+		if res.idle != nil {
+			body = &idleBody{ReadCloser: body, idle: res.idle}
+		}
+	} else {
+		started := time.Now()
+		bodyBytes, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return response, classifyError(err)
+		}
+		if len(exchanges) > 0 {
+			last := exchanges[len(exchanges)-1]
+			last.ResponseBodySize = int64(len(bodyBytes))
+			last.Receive = time.Since(started)
+		}
+		text, bytes, err = DecompressBody(bodyBytes, encoding)
+		if err != nil {
+			return response, &Error{Kind: ErrDecompression, Op: encoding[0], Err: err}
 		}
 	}
+	headers := resp.Header.Clone()
 
 	var cookies []Cookie
 	for _, v := range res.client.Jar.Cookies(res.req.URL) {
-		cookies = append(cookies, Cookie{
-			Name:    v.Name,
-			Value:   v.Value,
-			Path:    v.Path,
-			Domain:  v.Domain,
-			Expires: v.Expires,
-			JSONExpires: Time{
-				Time: v.Expires,
-			},
-			RawExpires: v.RawExpires,
-			MaxAge:     v.MaxAge,
-			Secure:     v.Secure,
-			HTTPOnly:   v.HttpOnly,
-			SameSite:   v.SameSite,
-			Raw:        v.Raw,
-			Unparsed:   v.Unparsed,
-		})
+		cookies = append(cookies, convertFHTTPCookie(v))
+	}
+	var responseCookies []Cookie
+	for _, v := range resp.Cookies() {
+		responseCookies = append(responseCookies, convertFHTTPCookie(v))
 	}
 
 	return Response{
@@ -216,6 +352,10 @@ func dispatcher(res fullRequest) (response Response, err error) {
 		StatusCode: resp.StatusCode,
 		Bytes:      bytes,
 		Text:       text,
+		Body:       body,
+
+		ResponseCookies: responseCookies,
+		Exchanges:       exchanges,
 	}, nil
 
 }
@@ -226,19 +366,39 @@ func (client *CycleTLS) Queue(URL string, options Options, Method string) {
 	options.URL = URL
 	options.Method = Method
 	//TODO add timestamp to request
-	opt := cycleTLSRequest{"Queued Request", options, client.CookieJar}
-	response := processRequest(opt)
+	opt := cycleTLSRequest{"Queued Request", options, client.CookieJar, context.Background()}
+	response, err := processRequest(opt)
+	if err != nil {
+		log.Println("Queue:", err)
+		return
+	}
 	client.ReqChan <- response
 }
 
 // Do creates a single request
 func (client *CycleTLS) Do(URL string, options Options, Method string) (response Response, err error) {
+	return client.DoContext(context.Background(), URL, options, Method)
+}
+
+// DoContext creates a single request bound to ctx. Cancelling ctx aborts the
+// dial, the proxy CONNECT, the TLS handshake and the body read.
+func (client *CycleTLS) DoContext(ctx context.Context, URL string, options Options, Method string) (response Response, err error) {
+	if client.Cassette != nil {
+		return client.Cassette.do(ctx, client, URL, options, Method)
+	}
+	return client.do(ctx, URL, options, Method)
+}
+
+func (client *CycleTLS) do(ctx context.Context, URL string, options Options, Method string) (response Response, err error) {
 
 	options.URL = URL
 	options.Method = Method
-	opt := cycleTLSRequest{"cycleTLSRequest", options, client.CookieJar}
+	opt := cycleTLSRequest{"cycleTLSRequest", options, client.CookieJar, ctx}
 
-	res := processRequest(opt)
+	res, err := processRequest(opt)
+	if err != nil {
+		return response, err
+	}
 	response, err = dispatcher(res)
 	if err != nil {
 		return response, err
@@ -249,12 +409,8 @@ func (client *CycleTLS) Do(URL string, options Options, Method string) (response
 
 //TODO rename this
 
-func getNewJar() *cookiejar.Jar {
-	jar, err := cookiejar.New(nil)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return jar
+func getNewJar() *Jar {
+	return NewJar()
 }
 
 func New(workers ...bool) CycleTLS {
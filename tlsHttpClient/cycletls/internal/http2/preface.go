@@ -0,0 +1,50 @@
+package http2
+
+// Preface is what a connection sends after the client preface: a SETTINGS
+// frame with Settings in their order, a connection WINDOW_UPDATE of
+// WindowUpdate, none when 0, and Priorities.
+type Preface struct {
+	Settings     []Setting
+	WindowUpdate uint32
+	Priorities   []PrefacePriority
+}
+
+// PrefacePriority is a PRIORITY frame for StreamID.
+type PrefacePriority struct {
+	StreamID uint32
+	PriorityParam
+}
+
+// write writes the frames of p. It returns the connection and stream
+// windows they give the peer and the stream of the first request, the one
+// after the streams of the PRIORITY frames if they go past nextStreamID.
+func (p *Preface) write(fr *Framer, nextStreamID uint32) (connFlow, streamFlow int32, firstStreamID uint32) {
+	connFlow, streamFlow, firstStreamID = initialWindowSize, initialWindowSize, nextStreamID
+	fr.WriteSettings(p.Settings...)
+	for _, s := range p.Settings {
+		if s.ID == SettingInitialWindowSize {
+			streamFlow = int32(s.Val)
+		}
+	}
+	if p.WindowUpdate != 0 {
+		fr.WriteWindowUpdate(0, p.WindowUpdate)
+		connFlow += int32(p.WindowUpdate)
+	}
+	for _, priority := range p.Priorities {
+		fr.WritePriority(priority.StreamID, priority.PriorityParam)
+		if priority.StreamID >= firstStreamID {
+			firstStreamID = (priority.StreamID + 1) | 1
+		}
+	}
+	return connFlow, streamFlow, firstStreamID
+}
+
+// headerTableSize returns the HEADER_TABLE_SIZE p announces.
+func (p *Preface) headerTableSize() uint32 {
+	for _, s := range p.Settings {
+		if s.ID == SettingHeaderTableSize {
+			return s.Val
+		}
+	}
+	return initialHeaderTableSize
+}
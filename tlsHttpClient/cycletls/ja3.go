@@ -0,0 +1,282 @@
+package cycletls
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidJA3 is matched by JA3 strings ParseJA3 rejects.
+var ErrInvalidJA3 = errors.New("cycletls: invalid ja3")
+
+// JA3 is a parsed JA3 fingerprint: the TLS version, cipher suites,
+// extensions, elliptic curves and point formats of a ClientHello, in the
+// order they are sent.
+type JA3 struct {
+	Version      uint16
+	Ciphers      []uint16
+	Extensions   []uint16
+	Curves       []uint16
+	PointFormats []uint8
+}
+
+// JA3Error describes the part of a JA3 string that is invalid, it matches
+// ErrInvalidJA3 and, for extensions CycleTLS can not build,
+// ErrUnsupportedExtension.
+type JA3Error struct {
+	// Field is version, ciphers, extensions, curves or pointFormats, empty
+	// when the string does not have five fields
+	Field string
+	// Index is the position of the value in its field, -1 for the whole
+	// field
+	Index int
+	Value string
+	Err   error
+}
+
+func (e *JA3Error) Error() string {
+	switch {
+	case e.Field == "":
+		return fmt.Sprintf("%s: %v", ErrInvalidJA3, e.Err)
+	case e.Index < 0:
+		return fmt.Sprintf("%s: %s: %v", ErrInvalidJA3, e.Field, e.Err)
+	}
+	return fmt.Sprintf("%s: %s[%d] %q: %v", ErrInvalidJA3, e.Field, e.Index, e.Value, e.Err)
+}
+
+func (e *JA3Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *JA3Error) Is(target error) bool {
+	return target == ErrInvalidJA3
+}
+
+// ja3Fields names the fields of a JA3 string in order.
+var ja3Fields = [...]string{"version", "ciphers", "extensions", "curves", "pointFormats"}
+
+// ParseJA3 parses and validates a JA3 string. Every value must be a decimal
+// number of the right size, there must be at least one cipher, extensions
+// may not repeat and must be ones CycleTLS can build.
+func ParseJA3(ja3 string) (JA3, error) {
+	tokens := strings.Split(strings.TrimSpace(ja3), ",")
+	if len(tokens) != len(ja3Fields) {
+		return JA3{}, &JA3Error{Index: -1, Err: fmt.Errorf("want %d comma separated fields, got %d", len(ja3Fields), len(tokens))}
+	}
+
+	var (
+		parsed JA3
+		err    error
+	)
+	version, err := parseJA3List(ja3Fields[0], tokens[0], 16)
+	if err != nil {
+		return JA3{}, err
+	}
+	if len(version) != 1 {
+		return JA3{}, &JA3Error{Field: ja3Fields[0], Index: -1, Value: tokens[0], Err: errors.New("want a single version")}
+	}
+	parsed.Version = uint16(version[0])
+
+	if parsed.Ciphers, err = parseJA3Uint16s(ja3Fields[1], tokens[1]); err != nil {
+		return JA3{}, err
+	}
+	if len(parsed.Ciphers) == 0 {
+		return JA3{}, &JA3Error{Field: ja3Fields[1], Index: -1, Err: errors.New("no cipher suites")}
+	}
+
+	if parsed.Extensions, err = parseJA3Uint16s(ja3Fields[2], tokens[2]); err != nil {
+		return JA3{}, err
+	}
+	supported := genMap()
+	seen := make(map[uint16]bool, len(parsed.Extensions))
+	for i, ext := range parsed.Extensions {
+		value := strconv.Itoa(int(ext))
+		if seen[ext] {
+			return JA3{}, &JA3Error{Field: ja3Fields[2], Index: i, Value: value, Err: errors.New("duplicate extension")}
+		}
+		seen[ext] = true
+		if _, ok := supported[value]; !ok && ext != 10 && ext != 11 {
+			return JA3{}, &JA3Error{Field: ja3Fields[2], Index: i, Value: value, Err: raiseExtensionError(value)}
+		}
+	}
+
+	if parsed.Curves, err = parseJA3Uint16s(ja3Fields[3], tokens[3]); err != nil {
+		return JA3{}, err
+	}
+	formats, err := parseJA3List(ja3Fields[4], tokens[4], 8)
+	if err != nil {
+		return JA3{}, err
+	}
+	for _, f := range formats {
+		parsed.PointFormats = append(parsed.PointFormats, uint8(f))
+	}
+	return parsed, nil
+}
+
+func parseJA3Uint16s(field, token string) ([]uint16, error) {
+	values, err := parseJA3List(field, token, 16)
+	if err != nil {
+		return nil, err
+	}
+	var parsed []uint16
+	for _, v := range values {
+		parsed = append(parsed, uint16(v))
+	}
+	return parsed, nil
+}
+
+// parseJA3List parses the dash separated numbers of a field, an empty field
+// is an empty list.
+func parseJA3List(field, token string, bitSize int) ([]uint64, error) {
+	if token == "" {
+		return nil, nil
+	}
+	var values []uint64
+	for i, value := range strings.Split(token, "-") {
+		if value == "" {
+			return nil, &JA3Error{Field: field, Index: i, Value: value, Err: errors.New("empty value")}
+		}
+		v, err := strconv.ParseUint(value, 10, bitSize)
+		if err != nil {
+			var numErr *strconv.NumError
+			if errors.As(err, &numErr) {
+				err = numErr.Err
+			}
+			return nil, &JA3Error{Field: field, Index: i, Value: value, Err: err}
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// String returns the JA3 string, ParseJA3 of it gives back j.
+func (j JA3) String() string {
+	formats := make([]uint16, len(j.PointFormats))
+	for i, f := range j.PointFormats {
+		formats[i] = uint16(f)
+	}
+	return strings.Join([]string{
+		strconv.Itoa(int(j.Version)),
+		joinJA3(j.Ciphers),
+		joinJA3(j.Extensions),
+		joinJA3(j.Curves),
+		joinJA3(formats),
+	}, ",")
+}
+
+func joinJA3(values []uint16) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(int(v))
+	}
+	return strings.Join(parts, "-")
+}
+
+// Hash returns the MD5 of the JA3 string in hex, the usual short form of a
+// JA3 fingerprint.
+func (j JA3) Hash() string {
+	sum := md5.Sum([]byte(j.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// JA3Diff is a field that differs between two fingerprints.
+type JA3Diff struct {
+	Field string
+	// Removed holds the values only in the receiver of Diff, Added the
+	// values only in its argument
+	Removed []uint16
+	Added   []uint16
+	// Reordered is set when the values they share come in a different
+	// order
+	Reordered bool
+}
+
+func (d JA3Diff) String() string {
+	var parts []string
+	if len(d.Removed) > 0 {
+		parts = append(parts, "removed "+joinJA3(d.Removed))
+	}
+	if len(d.Added) > 0 {
+		parts = append(parts, "added "+joinJA3(d.Added))
+	}
+	if d.Reordered {
+		parts = append(parts, "reordered")
+	}
+	return d.Field + ": " + strings.Join(parts, ", ")
+}
+
+// Diff returns the fields that differ between j and other, nil when they
+// are the same fingerprint.
+func (j JA3) Diff(other JA3) []JA3Diff {
+	formats := func(f []uint8) []uint16 {
+		values := make([]uint16, len(f))
+		for i, v := range f {
+			values[i] = uint16(v)
+		}
+		return values
+	}
+	var diffs []JA3Diff
+	for i, pair := range [][2][]uint16{
+		{{j.Version}, {other.Version}},
+		{j.Ciphers, other.Ciphers},
+		{j.Extensions, other.Extensions},
+		{j.Curves, other.Curves},
+		{formats(j.PointFormats), formats(other.PointFormats)},
+	} {
+		if diff, ok := diffJA3List(ja3Fields[i], pair[0], pair[1]); ok {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs
+}
+
+func diffJA3List(field string, a, b []uint16) (JA3Diff, bool) {
+	diff := JA3Diff{Field: field, Removed: missingFrom(a, b), Added: missingFrom(b, a)}
+	diff.Reordered = !equalUint16s(commonOrder(a, b), commonOrder(b, a))
+	return diff, len(diff.Removed) > 0 || len(diff.Added) > 0 || diff.Reordered
+}
+
+// missingFrom returns the values of a that are not in b.
+func missingFrom(a, b []uint16) []uint16 {
+	in := make(map[uint16]bool, len(b))
+	for _, v := range b {
+		in[v] = true
+	}
+	var missing []uint16
+	for _, v := range a {
+		if !in[v] {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
+
+// commonOrder returns the values of a that are also in b, in the order of a.
+func commonOrder(a, b []uint16) []uint16 {
+	in := make(map[uint16]bool, len(b))
+	for _, v := range b {
+		in[v] = true
+	}
+	var common []uint16
+	for _, v := range a {
+		if in[v] {
+			common = append(common, v)
+		}
+	}
+	return common
+}
+
+func equalUint16s(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
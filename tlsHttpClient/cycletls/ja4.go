@@ -0,0 +1,467 @@
+package cycletls
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	utls "github.com/Danny-Dasilva/utls"
+)
+
+// ErrInvalidJA4 is matched by JA4 strings ParseJA4 rejects.
+var ErrInvalidJA4 = errors.New("cycletls: invalid ja4")
+
+// JA4 is a JA4 fingerprint of a ClientHello sent over TCP. Ciphers and
+// Extensions are sorted, Extensions leaves out SNI and ALPN which are
+// given by SNI and ALPN, SignatureAlgorithms keep the order they are sent
+// in.
+type JA4 struct {
+	Version uint16
+	SNI     bool
+	// ALPN is the first and last character of the first ALPN protocol, 00
+	// without ALPN
+	ALPN                string
+	Ciphers             []uint16
+	Extensions          []uint16
+	SignatureAlgorithms []uint16
+}
+
+// ja4Versions maps the versions of JA4 to TLS versions.
+var ja4Versions = map[string]uint16{
+	"13": utls.VersionTLS13,
+	"12": utls.VersionTLS12,
+	"11": utls.VersionTLS11,
+	"10": utls.VersionTLS10,
+}
+
+// ja4ALPNProtocols are the ALPN protocols sent for the ALPN of a JA4.
+var ja4ALPNProtocols = map[string][]string{
+	"h2": {"h2", "http/1.1"},
+	"h1": {"http/1.1"},
+}
+
+// ja4Curves and ja4PointFormats are sent when the JA4 has the extensions,
+// JA4 does not record them.
+var (
+	ja4Curves       = []uint16{uint16(utls.X25519), uint16(utls.CurveP256), uint16(utls.CurveP384)}
+	ja4PointFormats = []uint8{0}
+)
+
+// JA4Error is returned for a JA4 string ParseJA4 rejects, it matches
+// ErrInvalidJA4 and, for extensions CycleTLS can not build,
+// ErrUnsupportedExtension.
+type JA4Error struct {
+	Err error
+}
+
+func (e *JA4Error) Error() string {
+	return fmt.Sprintf("%s: %v", ErrInvalidJA4, e.Err)
+}
+
+func (e *JA4Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *JA4Error) Is(target error) bool {
+	return target == ErrInvalidJA4
+}
+
+func invalidJA4(format string, args ...interface{}) error {
+	return &JA4Error{Err: fmt.Errorf(format, args...)}
+}
+
+// ParseJA4 parses a raw JA4 (JA4_r) such as
+// t13d1516h2_002f,0035,..._0005,000a,..._0403,0804,... A hashed JA4 can not
+// be parsed, the lists it hashes are lost.
+func ParseJA4(ja4r string) (JA4, error) {
+	parts := strings.Split(strings.TrimSpace(ja4r), "_")
+	if len(parts) < 3 || len(parts) > 4 {
+		return JA4{}, invalidJA4("want 3 or 4 underscore separated parts, got %d", len(parts))
+	}
+	prefix := parts[0]
+	if len(prefix) != 10 {
+		return JA4{}, invalidJA4("prefix %q is not 10 characters", prefix)
+	}
+	if prefix[0] != 't' {
+		return JA4{}, invalidJA4("protocol %q is not supported, only t (TCP) is", prefix[:1])
+	}
+	var parsed JA4
+	version, ok := ja4Versions[prefix[1:3]]
+	if !ok {
+		return JA4{}, invalidJA4("unsupported TLS version %q", prefix[1:3])
+	}
+	parsed.Version = version
+	switch prefix[3] {
+	case 'd':
+		parsed.SNI = true
+	case 'i':
+	default:
+		return JA4{}, invalidJA4("SNI %q is neither d nor i", prefix[3:4])
+	}
+	cipherCount, err := strconv.Atoi(prefix[4:6])
+	if err != nil {
+		return JA4{}, invalidJA4("cipher count %q is not a number", prefix[4:6])
+	}
+	extensionCount, err := strconv.Atoi(prefix[6:8])
+	if err != nil {
+		return JA4{}, invalidJA4("extension count %q is not a number", prefix[6:8])
+	}
+	parsed.ALPN = prefix[8:10]
+	if _, ok := ja4ALPNProtocols[parsed.ALPN]; !ok && parsed.ALPN != "00" {
+		return JA4{}, invalidJA4("unsupported ALPN %q", parsed.ALPN)
+	}
+
+	if len(parts[1]) == 12 && !strings.Contains(parts[1], ",") {
+		return JA4{}, invalidJA4("%q is a hashed JA4, use the raw JA4_r", ja4r)
+	}
+	if parsed.Ciphers, err = parseJA4List("ciphers", parts[1]); err != nil {
+		return JA4{}, err
+	}
+	if parsed.Extensions, err = parseJA4List("extensions", parts[2]); err != nil {
+		return JA4{}, err
+	}
+	if len(parts) == 4 {
+		if parsed.SignatureAlgorithms, err = parseJA4List("signature algorithms", parts[3]); err != nil {
+			return JA4{}, err
+		}
+	}
+
+	if len(parsed.Ciphers) == 0 {
+		return JA4{}, invalidJA4("no cipher suites")
+	}
+	if n := min99(len(parsed.Ciphers)); n != cipherCount {
+		return JA4{}, invalidJA4("prefix counts %d cipher suites, the list has %d", cipherCount, n)
+	}
+	if n := min99(parsed.extensionCount()); n != extensionCount {
+		return JA4{}, invalidJA4("prefix counts %d extensions, the list, SNI and ALPN make %d", extensionCount, n)
+	}
+	supported := genMap()
+	for _, ext := range parsed.Extensions {
+		switch ext {
+		case 0, 16:
+			return JA4{}, invalidJA4("extension %d is given by the prefix, not the list", ext)
+		case 10, 11:
+			continue
+		}
+		if _, ok := supported[strconv.Itoa(int(ext))]; !ok {
+			return JA4{}, &JA4Error{Err: raiseExtensionError(strconv.Itoa(int(ext)))}
+		}
+	}
+	if parsed.Version == utls.VersionTLS13 && !containsUint16(parsed.Extensions, 43) {
+		return JA4{}, invalidJA4("TLS 1.3 needs the supported_versions extension (43)")
+	}
+	if len(parsed.SignatureAlgorithms) > 0 && !containsUint16(parsed.Extensions, 13) {
+		return JA4{}, invalidJA4("signature algorithms without the signature_algorithms extension (13)")
+	}
+	return parsed, nil
+}
+
+// parseJA4List parses a comma separated list of 4 digit hex values, the
+// empty list is empty.
+func parseJA4List(name, list string) ([]uint16, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var values []uint16
+	for i, value := range strings.Split(list, ",") {
+		if len(value) != 4 {
+			return nil, invalidJA4("%s[%d] %q is not 4 hex digits", name, i, value)
+		}
+		v, err := strconv.ParseUint(value, 16, 16)
+		if err != nil {
+			return nil, invalidJA4("%s[%d] %q is not 4 hex digits", name, i, value)
+		}
+		values = append(values, uint16(v))
+	}
+	return values, nil
+}
+
+func (j JA4) extensionCount() int {
+	n := len(j.Extensions)
+	if j.SNI {
+		n++
+	}
+	if j.ALPN != "00" {
+		n++
+	}
+	return n
+}
+
+func (j JA4) prefix() string {
+	version := "00"
+	for name, v := range ja4Versions {
+		if v == j.Version {
+			version = name
+		}
+	}
+	sni := "i"
+	if j.SNI {
+		sni = "d"
+	}
+	return fmt.Sprintf("t%s%s%02d%02d%s", version, sni, min99(len(j.Ciphers)), min99(j.extensionCount()), j.ALPN)
+}
+
+// lists returns the sorted ciphers and the sorted extensions followed by the
+// signature algorithms, in hex.
+func (j JA4) lists() (string, string) {
+	extensions := sortedJA4Hex(j.Extensions)
+	if len(j.SignatureAlgorithms) > 0 {
+		extensions += "_" + joinJA4Hex(j.SignatureAlgorithms)
+	}
+	return sortedJA4Hex(j.Ciphers), extensions
+}
+
+// String returns the JA4 fingerprint.
+func (j JA4) String() string {
+	ciphers, extensions := j.lists()
+	return j.prefix() + "_" + ja4Hash(ciphers) + "_" + ja4Hash(extensions)
+}
+
+// Raw returns the JA4_r fingerprint, ParseJA4 of it gives back j with its
+// lists sorted.
+func (j JA4) Raw() string {
+	ciphers, extensions := j.lists()
+	return j.prefix() + "_" + ciphers + "_" + extensions
+}
+
+// ja4Hash returns the first 12 hex characters of the SHA-256 of list.
+func ja4Hash(list string) string {
+	if list == "" {
+		return "000000000000"
+	}
+	sum := sha256.Sum256([]byte(list))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func joinJA4Hex(values []uint16) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%04x", v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func sortedJA4Hex(values []uint16) string {
+	sorted := append([]uint16(nil), values...)
+	sort.Slice(sorted, func(i, k int) bool { return sorted[i] < sorted[k] })
+	return joinJA4Hex(sorted)
+}
+
+func min99(n int) int {
+	if n > 99 {
+		return 99
+	}
+	return n
+}
+
+func containsUint16(values []uint16, v uint16) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// JA4ToSpec creates a ClientHelloSpec from a JA4_r string. JA4 does not keep
+// the order of ciphers and extensions: they are sent sorted, with SNI first
+// and padding and pre_shared_key last. Curves and point formats, which JA4
+// leaves out, are X25519, P-256 and P-384 and uncompressed.
+func JA4ToSpec(ja4r string, userAgent string) (*utls.ClientHelloSpec, error) {
+	return ja4Spec(ja4r, parseUserAgent(userAgent))
+}
+
+func ja4Spec(ja4r string, family string) (*utls.ClientHelloSpec, error) {
+	parsed, err := ParseJA4(ja4r)
+	if err != nil {
+		return nil, err
+	}
+
+	extensions := append([]uint16(nil), parsed.Extensions...)
+	if parsed.ALPN != "00" {
+		extensions = append(extensions, 16)
+	}
+	sort.Slice(extensions, func(i, k int) bool { return ja4ExtensionRank(extensions[i], extensions[k]) })
+	if parsed.SNI {
+		extensions = append([]uint16{0}, extensions...)
+	}
+
+	extMap := genMap()
+	if len(parsed.SignatureAlgorithms) > 0 {
+		algorithms := make([]utls.SignatureScheme, len(parsed.SignatureAlgorithms))
+		for i, algorithm := range parsed.SignatureAlgorithms {
+			algorithms[i] = utls.SignatureScheme(algorithm)
+		}
+		extMap["13"] = &utls.SignatureAlgorithmsExtension{SupportedSignatureAlgorithms: algorithms}
+	}
+	extMap["16"] = &utls.ALPNExtension{AlpnProtocols: ja4ALPNProtocols[parsed.ALPN]}
+	versions := []uint16{utls.GREASE_PLACEHOLDER}
+	for _, version := range []uint16{utls.VersionTLS13, utls.VersionTLS12, utls.VersionTLS11, utls.VersionTLS10} {
+		if version <= parsed.Version {
+			versions = append(versions, version)
+		}
+	}
+	extMap["43"] = &utls.SupportedVersionsExtension{Versions: versions}
+
+	legacyVersion := parsed.Version
+	if legacyVersion > utls.VersionTLS12 {
+		legacyVersion = utls.VersionTLS12
+	}
+	return ja3Spec(JA3{
+		Version:      legacyVersion,
+		Ciphers:      parsed.Ciphers,
+		Extensions:   extensions,
+		Curves:       ja4Curves,
+		PointFormats: ja4PointFormats,
+	}, family, extMap)
+}
+
+// ja4ExtensionRank orders extensions by number with padding and
+// pre_shared_key, which must come last, at the end.
+func ja4ExtensionRank(a, b uint16) bool {
+	last := func(ext uint16) int {
+		switch ext {
+		case 21:
+			return 1
+		case 41:
+			return 2
+		}
+		return 0
+	}
+	if last(a) != last(b) {
+		return last(a) < last(b)
+	}
+	return a < b
+}
+
+// JA4FromSpec returns the JA4 of the ClientHello spec sends, GREASE values
+// are left out. The padding extension counts although it is only sent when
+// the ClientHello needs it.
+func JA4FromSpec(spec *utls.ClientHelloSpec) (JA4, error) {
+	ja4 := JA4{Version: spec.TLSVersMax, ALPN: "00"}
+	if ja4.Version == 0 {
+		ja4.Version = utls.VersionTLS12
+	}
+	for _, suite := range spec.CipherSuites {
+		if !isGREASE(suite) {
+			ja4.Ciphers = append(ja4.Ciphers, suite)
+		}
+	}
+	for _, extension := range spec.Extensions {
+		id, ok := extensionID(extension)
+		if !ok {
+			return JA4{}, fmt.Errorf("cycletls: no extension id for %T", extension)
+		}
+		if isGREASE(id) {
+			continue
+		}
+		switch e := extension.(type) {
+		case *utls.SNIExtension:
+			ja4.SNI = true
+			continue
+		case *utls.ALPNExtension:
+			if len(e.AlpnProtocols) > 0 {
+				ja4.ALPN = ja4ALPN(e.AlpnProtocols[0])
+			}
+			continue
+		case *utls.SignatureAlgorithmsExtension:
+			for _, algorithm := range e.SupportedSignatureAlgorithms {
+				ja4.SignatureAlgorithms = append(ja4.SignatureAlgorithms, uint16(algorithm))
+			}
+		case *utls.SupportedVersionsExtension:
+			ja4.Version = 0
+			for _, version := range e.Versions {
+				if !isGREASE(version) && version > ja4.Version {
+					ja4.Version = version
+				}
+			}
+		}
+		ja4.Extensions = append(ja4.Extensions, id)
+	}
+	return ja4, nil
+}
+
+// ja4ALPN returns the first and last characters of protocol, or of its hex
+// form when they are not alphanumeric.
+func ja4ALPN(protocol string) string {
+	if protocol == "" {
+		return "00"
+	}
+	first, last := protocol[0], protocol[len(protocol)-1]
+	if isAlphanumeric(first) && isAlphanumeric(last) {
+		return string([]byte{first, last})
+	}
+	h := hex.EncodeToString([]byte(protocol))
+	return string([]byte{h[0], h[len(h)-1]})
+}
+
+func isAlphanumeric(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// isGREASE reports whether v is one of the reserved GREASE values of RFC
+// 8701.
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// extensionID returns the extension type utls sends for extension.
+func extensionID(extension utls.TLSExtension) (uint16, bool) {
+	switch e := extension.(type) {
+	case *utls.SNIExtension:
+		return 0, true
+	case *utls.StatusRequestExtension:
+		return 5, true
+	case *utls.SupportedCurvesExtension:
+		return 10, true
+	case *utls.SupportedPointsExtension:
+		return 11, true
+	case *utls.SignatureAlgorithmsExtension:
+		return 13, true
+	case *utls.ALPNExtension:
+		return 16, true
+	case *utls.StatusRequestV2Extension:
+		return 17, true
+	case *utls.SCTExtension:
+		return 18, true
+	case *utls.UtlsPaddingExtension:
+		return 21, true
+	case *utls.UtlsExtendedMasterSecretExtension:
+		return 23, true
+	case *utls.CompressCertificateExtension:
+		return 27, true
+	case *utls.FakeRecordSizeLimitExtension:
+		return 28, true
+	case *utls.DelegatedCredentialsExtension:
+		return 34, true
+	case *utls.SessionTicketExtension:
+		return 35, true
+	case *utls.SupportedVersionsExtension:
+		return 43, true
+	case *utls.CookieExtension:
+		return 44, true
+	case *utls.PSKKeyExchangeModesExtension:
+		return 45, true
+	case *utls.SignatureAlgorithmsCertExtension:
+		return 50, true
+	case *utls.KeyShareExtension:
+		return 51, true
+	case *utls.NPNExtension:
+		return 13172, true
+	case *utls.ApplicationSettingsExtension:
+		return 17513, true
+	case *utls.FakeChannelIDExtension:
+		return 30032, true
+	case *utls.RenegotiationInfoExtension:
+		return 65281, true
+	case *utls.UtlsGREASEExtension:
+		return utls.GREASE_PLACEHOLDER, true
+	case *utls.GenericExtension:
+		return e.Id, true
+	}
+	return 0, false
+}
@@ -0,0 +1,49 @@
+package cycletls
+
+import (
+	"sync/atomic"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// PublicSuffixList provides the public suffix of a domain, the part under
+// which anyone can register names such as "com", "co.uk" or "github.io". A
+// Jar uses it to reject cookies set for a whole suffix.
+//
+// Implementations must be safe for concurrent use by multiple goroutines.
+// golang.org/x/net/publicsuffix.List is one.
+type PublicSuffixList interface {
+	// PublicSuffix returns the public suffix of domain.
+	PublicSuffix(domain string) string
+
+	// String returns a description of the source of this public suffix
+	// list.
+	String() string
+}
+
+// defaultSuffixList holds a suffixListValue, atomic.Value needs one
+// concrete type for every Store.
+var defaultSuffixList atomic.Value
+
+type suffixListValue struct {
+	list PublicSuffixList
+}
+
+func init() {
+	defaultSuffixList.Store(suffixListValue{publicsuffix.List})
+}
+
+// DefaultSuffixList returns the list new jars use,
+// golang.org/x/net/publicsuffix.List until SetDefaultSuffixList is called.
+func DefaultSuffixList() PublicSuffixList {
+	return defaultSuffixList.Load().(suffixListValue).list
+}
+
+// SetDefaultSuffixList replaces the default list. Jars keep the list they
+// were made with, since it decides where their cookies are stored.
+func SetDefaultSuffixList(list PublicSuffixList) {
+	if list == nil {
+		panic("cycletls: nil public suffix list")
+	}
+	defaultSuffixList.Store(suffixListValue{list})
+}
@@ -8,10 +8,11 @@ import (
 
 	"strings"
 	"sync"
+	"time"
 
 	http "github.com/Danny-Dasilva/fhttp"
-	"github.com/Danny-Dasilva/fhttp/http2"
 	utls "github.com/Danny-Dasilva/utls"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls/internal/http2"
 	"golang.org/x/net/proxy"
 )
 
@@ -21,7 +22,11 @@ type roundTripper struct {
 	sync.Mutex
 	// fix typing
 	JA3       string
+	JA4       string
 	UserAgent string
+	Browser   string // browser family, guessed from UserAgent when empty
+
+	HTTP2Settings *HTTP2Settings
 
 	cachedConnections map[string]net.Conn
 	cachedTransports  map[string]http.RoundTripper
@@ -43,14 +48,14 @@ func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 func (rt *roundTripper) getTransport(req *http.Request, addr string) error {
 	switch strings.ToLower(req.URL.Scheme) {
 	case "http":
-		rt.cachedTransports[addr] = &http.Transport{DialContext: rt.dialer.DialContext, DisableKeepAlives: true}
+		rt.cachedTransports[addr] = &http.Transport{DialContext: rt.dialContext, DisableKeepAlives: true}
 		return nil
 	case "https":
 	default:
 		return fmt.Errorf("invalid URL scheme: [%v]", req.URL.Scheme)
 	}
 
-	_, err := rt.dialTLS(context.Background(), "tcp", addr)
+	_, err := rt.dialTLS(req.Context(), "tcp", addr)
 	switch err {
 	case errProtocolNegotiated:
 	case nil:
@@ -73,10 +78,12 @@ func (rt *roundTripper) dialTLS(ctx context.Context, network, addr string) (net.
 		delete(rt.cachedConnections, addr)
 		return conn, nil
 	}
+	started := time.Now()
 	rawConn, err := rt.dialer.DialContext(ctx, network, addr)
 	if err != nil {
 		return nil, err
 	}
+	connected := time.Now()
 
 	var host string
 	if host, _, err = net.SplitHostPort(addr); err != nil {
@@ -84,7 +91,7 @@ func (rt *roundTripper) dialTLS(ctx context.Context, network, addr string) (net.
 	}
 	//////////////////
 
-	spec, err := StringToSpec(rt.JA3, rt.UserAgent)
+	spec, err := clientHelloSpec(rt.JA3, rt.JA4, browserFamily(rt.Browser, rt.UserAgent))
 	if err != nil {
 		return nil, err
 	}
@@ -98,15 +105,20 @@ func (rt *roundTripper) dialTLS(ctx context.Context, network, addr string) (net.
 		return nil, err
 	}
 
-	if err = conn.Handshake(); err != nil {
+	if err = withConnContext(ctx, conn, conn.Handshake); err != nil {
 		_ = conn.Close()
 
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
 		if err.Error() == "tls: CurvePreferences includes unsupported curve" {
 			//fix this
-			return nil, fmt.Errorf("conn.Handshake() error for tls 1.3 (please retry request): %+v", err)
+			return nil, &Error{Kind: ErrTLSHandshake, Op: "handshake for tls 1.3 (please retry request)", Err: err}
 		}
-		return nil, fmt.Errorf("uTlsConn.Handshake() error: %+v", err)
+		return nil, &Error{Kind: ErrTLSHandshake, Op: "handshake", Err: err}
 	}
+	recordDial(ctx, connected.Sub(started), time.Since(connected))
 
 	//////////
 	if rt.cachedTransports[addr] != nil {
@@ -117,10 +129,17 @@ func (rt *roundTripper) dialTLS(ctx context.Context, network, addr string) (net.
 	// of ALPN.
 	switch conn.ConnectionState().NegotiatedProtocol {
 	case http2.NextProtoTLS:
-		parsedUserAgent := parseUserAgent(rt.UserAgent)
+		// fhttp has HTTP/2 presets for Chrome and Firefox only, HTTP2Settings
+		// send their own frames instead
 		t2 := http2.Transport{DialTLS: rt.dialTLSHTTP2,
 			PushHandler: &http2.DefaultPushHandler{},
-			Navigator:   parsedUserAgent,
+			Navigator:   http2.Chrome,
+		}
+		if rt.HTTP2Settings != nil {
+			t2.Navigator = ""
+			t2.Preface = rt.HTTP2Settings.preface()
+		} else if browserFamily(rt.Browser, rt.UserAgent) == firefox {
+			t2.Navigator = http2.Firefox
 		}
 		rt.cachedTransports[addr] = &t2
 	default:
@@ -136,6 +155,16 @@ func (rt *roundTripper) dialTLS(ctx context.Context, network, addr string) (net.
 	return nil, errProtocolNegotiated
 }
 
+// dialContext dials a plain connection for http urls.
+func (rt *roundTripper) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	started := time.Now()
+	conn, err := rt.dialer.DialContext(ctx, network, addr)
+	if err == nil {
+		recordDial(ctx, time.Since(started), -1)
+	}
+	return conn, err
+}
+
 func (rt *roundTripper) dialTLSHTTP2(network, addr string, _ *utls.Config) (net.Conn, error) {
 	return rt.dialTLS(context.Background(), network, addr)
 }
@@ -149,23 +178,19 @@ func (rt *roundTripper) getDialTLSAddr(req *http.Request) string {
 }
 
 func newRoundTripper(browser browser, dialer ...proxy.ContextDialer) http.RoundTripper {
+	var contextDialer proxy.ContextDialer = proxy.Direct
 	if len(dialer) > 0 {
-
-		return &roundTripper{
-			dialer: dialer[0],
-
-			JA3:               browser.JA3,
-			UserAgent:         browser.UserAgent,
-			cachedTransports:  make(map[string]http.RoundTripper),
-			cachedConnections: make(map[string]net.Conn),
-		}
+		contextDialer = dialer[0]
 	}
 
 	return &roundTripper{
-		dialer: proxy.Direct,
+		dialer: contextDialer,
 
 		JA3:               browser.JA3,
+		JA4:               browser.JA4,
+		Browser:           browser.Browser,
 		UserAgent:         browser.UserAgent,
+		HTTP2Settings:     browser.HTTP2Settings,
 		cachedTransports:  make(map[string]http.RoundTripper),
 		cachedConnections: make(map[string]net.Conn),
 	}
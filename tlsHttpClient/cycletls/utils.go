@@ -1,19 +1,49 @@
 package cycletls
 
 import (
+	"context"
 	"crypto/sha256"
-	"log"
+	"fmt"
+	"io"
+	"net"
 	"strconv"
 	"strings"
+	"time"
 
+	http "github.com/Danny-Dasilva/fhttp"
 	utls "github.com/Danny-Dasilva/utls"
 )
 
 const (
 	chrome  = "chrome"  //chrome User agent enum
 	firefox = "firefox" //firefox User agent enum
+	safari  = "safari"  //safari User agent enum
 )
 
+// Browser families for Options.Browser. The family decides whether the
+// ClientHello carries GREASE values and which HTTP/2 preset is sent.
+const (
+	BrowserChrome  = chrome
+	BrowserFirefox = firefox
+	BrowserSafari  = safari
+)
+
+// browserFamily returns the family set in browser, or the one guessed from
+// the user agent.
+func browserFamily(browser, userAgent string) string {
+	switch browser {
+	case chrome, firefox, safari:
+		return browser
+	}
+	return parseUserAgent(userAgent)
+}
+
+// sendsGREASE reports whether browsers of family put GREASE values in their
+// ClientHello, Firefox does not.
+func sendsGREASE(family string) bool {
+	return family != firefox
+}
+
 func parseUserAgent(userAgent string) string {
 	switch {
 	case strings.Contains(strings.ToLower(userAgent), chrome):
@@ -26,80 +56,139 @@ func parseUserAgent(userAgent string) string {
 
 }
 
-// DecompressBody unzips compressed data
-func DecompressBody(Body []byte, encoding []string) (string, []byte) {
+// withConnContext runs fn, a blocking exchange over conn, and aborts it by
+// expiring the connection deadline as soon as ctx is done.
+func withConnContext(ctx context.Context, conn net.Conn, fn func() error) error {
+	if ctx.Done() == nil {
+		return fn()
+	}
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			_ = conn.SetDeadline(time.Unix(1, 0))
+		case <-stop:
+		}
+	}()
+	err := fn()
+	close(stop)
+	<-stopped
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// setContentLength applies a caller supplied body length to req, a negative
+// length leaves it unknown so the body goes out chunked.
+func setContentLength(req *http.Request, length int64) {
+	switch {
+	case length >= 0:
+		req.ContentLength = length
+	case req.ContentLength == 0:
+		// NewRequest could not size the reader, -1 forces chunked encoding
+		req.ContentLength = -1
+	default:
+		return
+	}
+	if req.ContentLength == 0 {
+		req.Body = http.NoBody
+		req.GetBody = func() (io.ReadCloser, error) { return http.NoBody, nil }
+	}
+}
+
+// parsePHeaderOrder lowercases order and checks it is a permutation of the
+// four request pseudo headers, fhttp silently drops the ones missing.
+func parsePHeaderOrder(order []string) ([]string, error) {
+	parsed := make([]string, 0, len(order))
+	seen := map[string]bool{}
+	for _, p := range order {
+		p = strings.ToLower(p)
+		if !strings.HasPrefix(p, ":") {
+			p = ":" + p
+		}
+		switch p {
+		case ":method", ":authority", ":scheme", ":path":
+		default:
+			return nil, fmt.Errorf("invalid pseudo header %q", p)
+		}
+		if seen[p] {
+			return nil, fmt.Errorf("duplicate pseudo header %q", p)
+		}
+		seen[p] = true
+		parsed = append(parsed, p)
+	}
+	if len(parsed) != len(DefaultPHeaderOrder) {
+		return nil, fmt.Errorf("pseudo header order %v must list %v", order, DefaultPHeaderOrder)
+	}
+	return parsed, nil
+}
+
+// DecompressBody unzips compressed data, on failure it returns the raw body
+// along with the error
+func DecompressBody(Body []byte, encoding []string) (string, []byte, error) {
 	var bytesDecompressed []byte = nil
 	var err error = nil
 	if len(encoding) > 0 {
-		if encoding[0] == "gzip" {
+		switch encoding[0] {
+		case "gzip":
 			bytesDecompressed, err = gUnzipData(Body)
-		} else if encoding[0] == "deflate" {
+		case "deflate":
 			bytesDecompressed, err = enflateData(Body)
-		} else if encoding[0] == "br" {
+		case "br":
 			bytesDecompressed, err = unBrotliData(Body)
-		} else if encoding[0] == "zstd" {
+		case "zstd":
 			bytesDecompressed, err = unZstdData(Body)
+		default:
+			bytesDecompressed = Body
 		}
 	} else {
 		bytesDecompressed = Body
 	}
 	if err != nil {
-		bytesDecompressed = Body
-		log.Println(encoding[0], err)
+		return string(Body), Body, err
 	}
-	return string(bytesDecompressed), bytesDecompressed
+	return string(bytesDecompressed), bytesDecompressed, nil
 
 }
 
 // StringToSpec creates a ClientHelloSpec based on a JA3 string
 func StringToSpec(ja3 string, userAgent string) (*utls.ClientHelloSpec, error) {
-	parsedUserAgent := parseUserAgent(userAgent)
-	extMap := genMap()
-	tokens := strings.Split(ja3, ",")
-
-	version := tokens[0]
-	ciphers := strings.Split(tokens[1], "-")
-	extensions := strings.Split(tokens[2], "-")
-	curves := strings.Split(tokens[3], "-")
-	if len(curves) == 1 && curves[0] == "" {
-		curves = []string{}
-	}
-	pointFormats := strings.Split(tokens[4], "-")
-	if len(pointFormats) == 1 && pointFormats[0] == "" {
-		pointFormats = []string{}
+	parsed, err := ParseJA3(ja3)
+	if err != nil {
+		return nil, err
 	}
+	return ja3Spec(parsed, parseUserAgent(userAgent), genMap())
+}
+
+// ja3Spec builds the spec of a parsed JA3 for a browser family, extMap holds
+// the extensions it may use.
+func ja3Spec(parsed JA3, family string, extMap map[string]utls.TLSExtension) (*utls.ClientHelloSpec, error) {
+	grease := sendsGREASE(family)
+
 	// parse curves
 	var targetCurves []utls.CurveID
-	targetCurves = append(targetCurves, utls.CurveID(utls.GREASE_PLACEHOLDER)) //append grease for Chrome browsers
-	for _, c := range curves {
-		cid, err := strconv.ParseUint(c, 10, 16)
-		if err != nil {
-			return nil, err
-		}
-		targetCurves = append(targetCurves, utls.CurveID(cid))
+	if grease {
+		targetCurves = append(targetCurves, utls.CurveID(utls.GREASE_PLACEHOLDER)) //append grease for Chrome browsers
+	}
+	for _, c := range parsed.Curves {
+		targetCurves = append(targetCurves, utls.CurveID(c))
 		// if cid != uint64(utls.CurveP521) {
 		// CurveP521 sometimes causes handshake errors
 		// }
 	}
 	extMap["10"] = &utls.SupportedCurvesExtension{Curves: targetCurves}
 
-	// parse point formats
-	var targetPointFormats []byte
-	for _, p := range pointFormats {
-		pid, err := strconv.ParseUint(p, 10, 8)
-		if err != nil {
-			return nil, err
-		}
-		targetPointFormats = append(targetPointFormats, byte(pid))
+	if !grease {
+		dropGREASE(extMap)
 	}
-	extMap["11"] = &utls.SupportedPointsExtension{SupportedPoints: targetPointFormats}
+
+	// parse point formats
+	extMap["11"] = &utls.SupportedPointsExtension{SupportedPoints: parsed.PointFormats}
 
 	// set extension 43
-	vid64, err := strconv.ParseUint(version, 10, 16)
-	if err != nil {
-		return nil, err
-	}
-	vid := uint16(vid64)
 	// extMap["43"] = &utls.SupportedVersionsExtension{
 	// 	Versions: []uint16{
 	// 		utls.VersionTLS12,
@@ -109,16 +198,17 @@ func StringToSpec(ja3 string, userAgent string) (*utls.ClientHelloSpec, error) {
 	// build extensions list
 	var exts []utls.TLSExtension
 	//Optionally Add Chrome Grease Extension
-	if parsedUserAgent == chrome {
+	if grease {
 		exts = append(exts, &utls.UtlsGREASEExtension{})
 	}
-	for _, e := range extensions {
+	for _, ext := range parsed.Extensions {
+		e := strconv.Itoa(int(ext))
 		te, ok := extMap[e]
 		if !ok {
 			return nil, raiseExtensionError(e)
 		}
 		// //Optionally add Chrome Grease Extension
-		if e == "21" && parsedUserAgent == chrome {
+		if e == "21" && grease {
 			exts = append(exts, &utls.UtlsGREASEExtension{})
 		}
 		exts = append(exts, te)
@@ -137,17 +227,10 @@ func StringToSpec(ja3 string, userAgent string) (*utls.ClientHelloSpec, error) {
 	// build CipherSuites
 	var suites []uint16
 	//Optionally Add Chrome Grease Extension
-	if parsedUserAgent == chrome {
+	if grease {
 		suites = append(suites, utls.GREASE_PLACEHOLDER)
 	}
-	for _, c := range ciphers {
-		cid, err := strconv.ParseUint(c, 10, 16)
-		if err != nil {
-			return nil, err
-		}
-		suites = append(suites, uint16(cid))
-	}
-	_ = vid
+	suites = append(suites, parsed.Ciphers...)
 	return &utls.ClientHelloSpec{
 		// TLSVersMin:         vid,
 		// TLSVersMax:         vid,
@@ -158,6 +241,41 @@ func StringToSpec(ja3 string, userAgent string) (*utls.ClientHelloSpec, error) {
 	}, nil
 }
 
+// clientHelloSpec builds the spec of ja4r when it is set, of ja3 otherwise.
+func clientHelloSpec(ja3, ja4r, family string) (*utls.ClientHelloSpec, error) {
+	if ja4r != "" {
+		return ja4Spec(ja4r, family)
+	}
+	parsed, err := ParseJA3(ja3)
+	if err != nil {
+		return nil, err
+	}
+	return ja3Spec(parsed, family, genMap())
+}
+
+// dropGREASE removes the GREASE values of the supported versions and key
+// share extensions of extMap.
+func dropGREASE(extMap map[string]utls.TLSExtension) {
+	if versions, ok := extMap["43"].(*utls.SupportedVersionsExtension); ok {
+		var kept []uint16
+		for _, version := range versions.Versions {
+			if version != utls.GREASE_PLACEHOLDER {
+				kept = append(kept, version)
+			}
+		}
+		extMap["43"] = &utls.SupportedVersionsExtension{Versions: kept}
+	}
+	if keyShares, ok := extMap["51"].(*utls.KeyShareExtension); ok {
+		var kept []utls.KeyShare
+		for _, share := range keyShares.KeyShares {
+			if share.Group != utls.CurveID(utls.GREASE_PLACEHOLDER) {
+				kept = append(kept, share)
+			}
+		}
+		extMap["51"] = &utls.KeyShareExtension{KeyShares: kept}
+	}
+}
+
 func genMap() (extMap map[string]utls.TLSExtension) {
 	extMap = map[string]utls.TLSExtension{
 		"0": &utls.SNIExtension{},
@@ -0,0 +1,338 @@
+package tlsHttpClient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DownloadProgress is reported to the progress callback while a download runs.
+type DownloadProgress struct {
+	// Done is the number of bytes on disk, including a resumed prefix
+	Done int64
+	// Total is the full size of the file, -1 when the server did not tell
+	Total int64
+	// Rate is the transfer rate of the current attempt in bytes per second
+	Rate float64
+}
+
+type ProgressFunc func(progress DownloadProgress)
+
+const (
+	downloadPartSuffix = ".part"
+	downloadInfoSuffix = ".part.info"
+)
+
+// errDownloadStatus is wrapped by downloads answered with a status code that
+// is neither a file nor a part of one.
+var errDownloadStatus = errors.New("download: unexpected status code")
+
+type progressWriter struct {
+	writer   io.Writer
+	progress DownloadProgress
+	started  time.Time
+	received int64
+	callback ProgressFunc
+	idle     *idleTimer
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.idle.reset()
+	n, err := p.writer.Write(b)
+	p.progress.Done += int64(n)
+	p.received += int64(n)
+	if elapsed := time.Since(p.started).Seconds(); elapsed > 0 {
+		p.progress.Rate = float64(p.received) / elapsed
+	}
+	if p.callback != nil {
+		p.callback(p.progress)
+	}
+	return n, err
+}
+
+// SetDownloadProgress sets the callback Download reports progress to after
+// every chunk written to disk.
+func (r *Request) SetDownloadProgress(callback ProgressFunc) *Request {
+	r.DownloadProgress = callback
+	return r
+}
+
+// idleTimer cancels a download that receives nothing for timeout, a nil
+// idleTimer never does.
+type idleTimer struct {
+	timer   *time.Timer
+	timeout time.Duration
+	fired   int32
+}
+
+func newIdleTimer(timeout time.Duration, cancel context.CancelFunc) *idleTimer {
+	t := &idleTimer{timeout: timeout}
+	t.timer = time.AfterFunc(timeout, func() {
+		atomic.StoreInt32(&t.fired, 1)
+		cancel()
+	})
+	return t
+}
+
+func (t *idleTimer) reset() {
+	if t != nil {
+		t.timer.Reset(t.timeout)
+	}
+}
+
+func (t *idleTimer) stop() {
+	if t != nil {
+		t.timer.Stop()
+	}
+}
+
+// err returns the error of a download cancelled for being idle, err
+// otherwise.
+func (t *idleTimer) err(err error) error {
+	if err == nil || t == nil || atomic.LoadInt32(&t.fired) == 0 {
+		return err
+	}
+	return fmt.Errorf("download: nothing received for %v: %w", t.timeout, ErrTimeout)
+}
+
+// Download streams url to path. Data is written to path+".part" first and
+// renamed once complete, so an interrupted download resumes from where it
+// stopped on the next attempt or the next call, using Range and If-Range.
+// Attempts are retried and spaced out by the retry policy, as with Execute a
+// response is retried for its status code. The timeout of the request is the
+// longest the download may receive nothing, not a limit on the whole
+// transfer.
+func (r *Request) Download(url, path string) (*Response, error) {
+	if r.err != nil {
+		r.Client.runErrorHooks(r, r.err)
+		return nil, r.err
+	}
+	attempts := r.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	policy := r.retryPolicy()
+
+	var err error
+	var resp *Response
+	for attempt := 1; ; attempt++ {
+		resp, err = r.download(url, path)
+		if resp != nil {
+			resp.Attempts = attempt
+		}
+		if err == nil {
+			break
+		}
+		if ctxErr := r.Context().Err(); ctxErr != nil {
+			r.Client.runErrorHooks(r, ctxErr)
+			return resp, ctxErr
+		}
+		retryErr := err
+		if errors.Is(err, errDownloadStatus) {
+			retryErr = nil
+		}
+		if attempt >= attempts || !policy.shouldRetry(r, resp, retryErr) {
+			break
+		}
+		r.Client.runRetryHooks(policy, attempt, resp, err)
+		if sleepErr := sleepContext(r.Context(), policy.wait(attempt, resp)); sleepErr != nil {
+			r.Client.runErrorHooks(r, sleepErr)
+			return resp, sleepErr
+		}
+	}
+	if err != nil {
+		r.Client.runErrorHooks(r, err)
+	}
+	return resp, err
+}
+
+func (r *Request) download(url, path string) (resp *Response, err error) {
+	// the request timeout would cut off every body that takes longer to
+	// read, an idle timer replaces it. What receive changes is put back so
+	// the request can be reused as it was set up.
+	timeout, parent := r.Timeout, r.ctx
+	headers, doNotParse := r.Headers.Clone(), r.DoNotParseResponse
+	idleTimeout := timeout
+	if idleTimeout == 0 {
+		idleTimeout = defaultDownloadIdleTimeout
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	var idle *idleTimer
+	if idleTimeout > 0 {
+		idle = newIdleTimer(time.Duration(idleTimeout)*time.Second, cancel)
+	}
+	r.Timeout, r.ctx = -1, ctx
+	defer func() {
+		idle.stop()
+		cancel()
+		r.Timeout, r.ctx = timeout, parent
+		r.Headers, r.DoNotParseResponse = headers, doNotParse
+		err = idle.err(err)
+	}()
+
+	return r.receive(url, path, idle)
+}
+
+func (r *Request) receive(url, path string, idle *idleTimer) (*Response, error) {
+	partPath := path + downloadPartSuffix
+	infoPath := path + downloadInfoSuffix
+
+	var offset int64
+	if stat, err := os.Stat(partPath); err == nil {
+		offset = stat.Size()
+	}
+	validator, _ := os.ReadFile(infoPath)
+
+	r.Method = MethodGet
+	r.URL = url
+	r.DoNotParseResponse = true
+	// ranges of compressed representations can not be resumed reliably
+	r.SetHeader("Accept-Encoding", "identity")
+	r.Headers.Del("Range")
+	r.Headers.Del("If-Range")
+	if offset > 0 {
+		r.SetHeader("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		if len(validator) > 0 {
+			r.SetHeader("If-Range", string(validator))
+		}
+	}
+
+	resp, err := r.Client.execute(r)
+	if err != nil {
+		return resp, err
+	}
+	if resp == nil {
+		return nil, errors.New("download: no response")
+	}
+	// a response from middleware may come without a body to stream
+	var body io.Reader = bytes.NewReader(resp.Bytes)
+	if resp.Body != nil {
+		defer resp.Body.Close()
+		body = resp.Body
+	}
+
+	total := int64(-1)
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case 206:
+		start, size, err := parseContentRange(resp.Headers.Get("Content-Range"))
+		if err != nil {
+			return resp, err
+		}
+		if start != offset {
+			return resp, fmt.Errorf("download: server resumed at byte %d, expected %d", start, offset)
+		}
+		total = size
+		flags |= os.O_APPEND
+	case 200:
+		// no range support or the file changed, start over
+		offset = 0
+		total = parseContentLength(resp.Headers.Get("Content-Length"))
+		flags |= os.O_TRUNC
+	case 416:
+		_, size, err := parseContentRange(resp.Headers.Get("Content-Range"))
+		if err == nil && size == offset {
+			return resp, finishDownload(partPath, infoPath, path)
+		}
+		_ = os.Remove(partPath)
+		_ = os.Remove(infoPath)
+		return resp, errors.New("download: requested range not satisfiable, partial file discarded")
+	default:
+		return resp, fmt.Errorf("%w %d", errDownloadStatus, resp.StatusCode)
+	}
+
+	if validator := downloadValidator(resp); validator != "" {
+		if err := os.WriteFile(infoPath, []byte(validator), 0o644); err != nil {
+			return resp, err
+		}
+	} else {
+		_ = os.Remove(infoPath)
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0o644)
+	if err != nil {
+		return resp, err
+	}
+	writer := &progressWriter{
+		writer:   file,
+		progress: DownloadProgress{Done: offset, Total: total},
+		started:  time.Now(),
+		callback: r.DownloadProgress,
+		idle:     idle,
+	}
+	_, err = io.Copy(writer, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return resp, err
+	}
+	if total >= 0 && writer.progress.Done != total {
+		return resp, fmt.Errorf("download: received %d of %d bytes", writer.progress.Done, total)
+	}
+	return resp, finishDownload(partPath, infoPath, path)
+}
+
+func finishDownload(partPath, infoPath, path string) error {
+	if err := os.Rename(partPath, path); err != nil {
+		return err
+	}
+	_ = os.Remove(infoPath)
+	return nil
+}
+
+// downloadValidator returns the value to send in If-Range when resuming,
+// a strong ETag is preferred over Last-Modified.
+func downloadValidator(resp *Response) string {
+	if etag := resp.Headers.Get("Etag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Headers.Get("Last-Modified")
+}
+
+// parseContentRange parses "bytes start-end/size", size is -1 when it is "*".
+func parseContentRange(value string) (int64, int64, error) {
+	spec := strings.TrimSpace(value)
+	if !strings.HasPrefix(spec, "bytes ") {
+		return 0, 0, fmt.Errorf("download: invalid Content-Range %q", value)
+	}
+	rng, size, ok := strings.Cut(strings.TrimPrefix(spec, "bytes "), "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("download: invalid Content-Range %q", value)
+	}
+	total := int64(-1)
+	if size != "*" {
+		var err error
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("download: invalid Content-Range %q", value)
+		}
+	}
+	if rng == "*" {
+		return 0, total, nil
+	}
+	start, _, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("download: invalid Content-Range %q", value)
+	}
+	first, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("download: invalid Content-Range %q", value)
+	}
+	return first, total, nil
+}
+
+func parseContentLength(value string) int64 {
+	length, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || length < 0 {
+		return -1
+	}
+	return length
+}
@@ -0,0 +1,19 @@
+package tlsHttpClient
+
+import (
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls"
+)
+
+// Errors returned by Execute, match them with errors.Is. Proxy refusals can
+// be inspected further with errors.As and *cycletls.ProxyConnectError.
+var (
+	ErrTimeout              = cycletls.ErrTimeout
+	ErrDNS                  = cycletls.ErrDNS
+	ErrProxyConnect         = cycletls.ErrProxyConnect
+	ErrTLSHandshake         = cycletls.ErrTLSHandshake
+	ErrUnsupportedExtension = cycletls.ErrUnsupportedExtension
+	ErrInvalidJA3           = cycletls.ErrInvalidJA3
+	ErrInvalidJA4           = cycletls.ErrInvalidJA4
+	ErrInvalidHTTP2Settings = cycletls.ErrInvalidHTTP2Settings
+	ErrDecompression        = cycletls.ErrDecompression
+)
@@ -0,0 +1,415 @@
+package tlsHttpClient
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	http "github.com/Danny-Dasilva/fhttp"
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls"
+)
+
+// HAR is an HTTP Archive 1.2 document, see
+// http://www.softwareishard.com/blog/har-12-spec/. Fields starting with an
+// underscore are custom fields allowed by the spec.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+	// Proxy is the proxy the request went through, password redacted
+	Proxy string `json:"_proxy,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARContent is the decompressed response body, Encoding is "base64" when
+// Text is not valid UTF-8.
+type HARContent struct {
+	Size        int64  `json:"size"`
+	Compression int64  `json:"compression,omitempty"`
+	MimeType    string `json:"mimeType"`
+	Text        string `json:"text,omitempty"`
+	Encoding    string `json:"encoding,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+}
+
+// HARTimings are in milliseconds, -1 when the phase does not apply or was not
+// measured. Ssl is included in Connect as the spec requires.
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	Ssl     float64 `json:"ssl"`
+}
+
+// HARRecorder collects every request a Client sends as HAR entries, redirect
+// hops and failed requests included. Request headers are listed in the order
+// they were written on the wire, response headers sorted by name.
+type HARRecorder struct {
+	mu      sync.Mutex
+	entries []HAREntry
+
+	path    string
+	saveMu  sync.Mutex
+	saveErr error
+}
+
+// NewHARRecorder returns a recorder that rewrites the HAR file at path after
+// every entry, or only keeps entries in memory when path is empty.
+func NewHARRecorder(path string) *HARRecorder {
+	return &HARRecorder{path: path}
+}
+
+// SetHARRecorder records all traffic of the client to recorder, nil stops
+// recording.
+func (c *Client) SetHARRecorder(recorder *HARRecorder) *Client {
+	c.har = recorder
+	return c
+}
+
+// HAR returns a copy of the document recorded so far.
+func (h *HARRecorder) HAR() HAR {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "tlsHttpClient", Version: harCreatorVersion()},
+		Entries: append([]HAREntry{}, h.entries...),
+	}}
+}
+
+// WriteTo writes the recorded HAR document to w.
+func (h *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(h.HAR(), "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// Save writes the recorded HAR document to the recorder's file, replacing it
+// atomically. It does nothing for a recorder without a path.
+func (h *HARRecorder) Save() error {
+	if h.path == "" {
+		return nil
+	}
+	h.saveMu.Lock()
+	defer h.saveMu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = h.WriteTo(tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), h.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Err returns the error of the last automatic save, nil when it succeeded.
+func (h *HARRecorder) Err() error {
+	h.saveMu.Lock()
+	defer h.saveMu.Unlock()
+	return h.saveErr
+}
+
+// record adds an entry for every exchange of a call, the body of the final
+// response is taken from resp.
+func (h *HARRecorder) record(exchanges []*cycletls.Exchange, resp cycletls.Response, streamed bool) {
+	if len(exchanges) == 0 {
+		return
+	}
+	entries := make([]HAREntry, 0, len(exchanges))
+	for i, exchange := range exchanges {
+		entry := newHAREntry(exchange)
+		if i == len(exchanges)-1 && exchange.Err == nil {
+			entry.Response.Content = newHARContent(exchange, resp, streamed)
+		}
+		entries = append(entries, entry)
+	}
+
+	h.mu.Lock()
+	h.entries = append(h.entries, entries...)
+	h.mu.Unlock()
+
+	if h.path != "" {
+		err := h.Save()
+		h.saveMu.Lock()
+		h.saveErr = err
+		h.saveMu.Unlock()
+	}
+}
+
+func newHAREntry(exchange *cycletls.Exchange) HAREntry {
+	timings := HARTimings{
+		Blocked: harMillis(exchange.Blocked),
+		DNS:     -1,
+		Connect: harMillis(exchange.Connect),
+		Send:    harMillis(exchange.Send),
+		Wait:    harMillis(exchange.Wait),
+		Receive: harMillis(exchange.Receive),
+		Ssl:     harMillis(exchange.TLS),
+	}
+	if timings.Ssl >= 0 && timings.Connect >= 0 {
+		timings.Connect += timings.Ssl
+	}
+	// send, wait and receive are required to be non-negative
+	for _, t := range []*float64{&timings.Send, &timings.Wait, &timings.Receive} {
+		if *t < 0 {
+			*t = 0
+		}
+	}
+	var total float64
+	for _, t := range []float64{timings.Blocked, timings.Connect, timings.Send, timings.Wait, timings.Receive} {
+		if t > 0 {
+			total += t
+		}
+	}
+
+	entry := HAREntry{
+		StartedDateTime: exchange.Started.UTC().Format(time.RFC3339Nano),
+		Time:            total,
+		Request:         newHARRequest(exchange),
+		Timings:         timings,
+		Proxy:           exchange.Proxy,
+	}
+	if exchange.Err != nil {
+		entry.Comment = exchange.Err.Error()
+		entry.Response = HARResponse{
+			Cookies:     []HARCookie{},
+			Headers:     []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+		return entry
+	}
+
+	entry.Response = HARResponse{
+		Status:      exchange.StatusCode,
+		StatusText:  exchange.Status,
+		HTTPVersion: exchange.Proto,
+		Cookies:     harResponseCookies(exchange.ResponseHeaders),
+		Headers:     harHeaders(exchange.ResponseHeaders),
+		Content:     HARContent{Size: 0, MimeType: exchange.ResponseHeaders.Get("Content-Type")},
+		RedirectURL: exchange.ResponseHeaders.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    exchange.ResponseBodySize,
+	}
+	// fhttp's Status is "200 OK"
+	if _, text, ok := strings.Cut(exchange.Status, " "); ok {
+		entry.Response.StatusText = text
+	}
+	return entry
+}
+
+func newHARRequest(exchange *cycletls.Exchange) HARRequest {
+	request := HARRequest{
+		Method:      exchange.Method,
+		URL:         exchange.URL,
+		HTTPVersion: exchange.Proto,
+		Cookies:     []HARCookie{},
+		Headers:     []HARNameValue{},
+		QueryString: []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    exchange.RequestBodySize,
+	}
+	if request.HTTPVersion == "" {
+		request.HTTPVersion = "HTTP/1.1"
+	}
+	var contentType string
+	for _, field := range exchange.RequestHeaders {
+		request.Headers = append(request.Headers, HARNameValue{Name: field.Name, Value: field.Value})
+		switch strings.ToLower(field.Name) {
+		case "cookie":
+			for _, pair := range strings.Split(field.Value, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+				if name != "" {
+					request.Cookies = append(request.Cookies, HARCookie{Name: name, Value: value})
+				}
+			}
+		case "content-type":
+			contentType = field.Value
+		}
+	}
+	if u, err := url.Parse(exchange.URL); err == nil {
+		if params, err := ParseParams(u.RawQuery); err == nil {
+			for _, param := range params {
+				request.QueryString = append(request.QueryString, HARNameValue{Name: param.Key, Value: param.Value})
+			}
+		}
+	}
+	if exchange.RequestBodySize > 0 {
+		request.PostData = &HARPostData{MimeType: contentType, Text: string(exchange.RequestBody)}
+		if int64(len(exchange.RequestBody)) < exchange.RequestBodySize {
+			request.PostData.Comment = "truncated"
+		}
+	}
+	return request
+}
+
+func newHARContent(exchange *cycletls.Exchange, resp cycletls.Response, streamed bool) HARContent {
+	content := HARContent{MimeType: exchange.ResponseHeaders.Get("Content-Type")}
+	if streamed {
+		content.Size = -1
+		content.Comment = "body streamed to the caller, not recorded"
+		return content
+	}
+	content.Size = int64(len(resp.Bytes))
+	if utf8.Valid(resp.Bytes) {
+		content.Text = resp.Text
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(resp.Bytes)
+		content.Encoding = "base64"
+	}
+	if encoding := exchange.ResponseHeaders.Get("Content-Encoding"); encoding != "" {
+		content.Comment = "decompressed from " + encoding
+		if exchange.ResponseBodySize >= 0 {
+			content.Compression = content.Size - exchange.ResponseBodySize
+		}
+	}
+	return content
+}
+
+// harHeaders lists headers sorted by name, the order they arrived in is not
+// kept by fhttp.
+func harHeaders(headers cycletls.Header) []HARNameValue {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fields := []HARNameValue{}
+	for _, name := range names {
+		for _, value := range headers[name] {
+			fields = append(fields, HARNameValue{Name: name, Value: value})
+		}
+	}
+	return fields
+}
+
+func harResponseCookies(headers cycletls.Header) []HARCookie {
+	cookies := []HARCookie{}
+	response := http.Response{Header: http.Header{"Set-Cookie": headers.Values("Set-Cookie")}}
+	for _, cookie := range response.Cookies() {
+		harCookie := HARCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HTTPOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		expires := cookie.Expires
+		if expires.IsZero() && cookie.RawExpires != "" {
+			expires, _ = cycletls.ParseDateString(cookie.RawExpires)
+		}
+		if !expires.IsZero() {
+			harCookie.Expires = expires.UTC().Format(time.RFC3339)
+		}
+		cookies = append(cookies, harCookie)
+	}
+	return cookies
+}
+
+func harMillis(d time.Duration) float64 {
+	if d < 0 {
+		return -1
+	}
+	return float64(d) / float64(time.Millisecond)
+}
+
+func harCreatorVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/quotpw/tlsHttpClient" {
+				return dep.Version
+			}
+		}
+		if info.Main.Path == "github.com/quotpw/tlsHttpClient" {
+			return info.Main.Version
+		}
+	}
+	return "devel"
+}
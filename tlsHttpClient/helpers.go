@@ -1,10 +1,12 @@
 package tlsHttpClient
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/textproto"
-	"net/url"
+	"os"
 	"strings"
 )
 
@@ -25,29 +27,107 @@ func NewMultipartFieldHeader(fieldName, filename string, contentType string) tex
 	return h
 }
 
-func prepareFormBody(forms map[string]string) string {
-	values := url.Values{}
-	for k, v := range forms {
-		values.Add(k, v)
-	}
-	return values.Encode()
+func prepareFormBody(forms Params) string {
+	return forms.Encode()
 }
 
-func prepareBody(r *Request) (string, string, bool) {
+// prepareBody returns the request body, its length (-1 when unknown) and
+// its content type. A nil body means the request has none.
+func prepareBody(r *Request) (io.Reader, int64, string, error) {
 	if r.Body != "" {
-		return r.Body, "text/plain", true
+		return strings.NewReader(r.Body), int64(len(r.Body)), "text/plain", nil
+	}
+	if r.BodyFile != "" {
+		f, err := os.Open(r.BodyFile)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		stat, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return nil, 0, "", err
+		}
+		return f, stat.Size(), "application/octet-stream", nil
+	}
+	if r.BodyReader != nil {
+		length := r.BodyLength
+		if length <= 0 {
+			length = -1
+		}
+		// the caller owns the reader, CycleTLS closes the body once sent
+		return io.NopCloser(r.BodyReader), length, "application/octet-stream", nil
 	}
 	if r.Forms != nil {
-		return prepareFormBody(*r.Forms), "application/x-www-form-urlencoded", true
+		body := prepareFormBody(r.Forms)
+		return strings.NewReader(body), int64(len(body)), "application/x-www-form-urlencoded", nil
+	}
+	if r.JsonBody != nil {
+		j, err := marshalJson(r.JsonBody)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		return bytes.NewReader(j), int64(len(j)), "application/json", nil
 	}
 	if r.Json != nil {
-		j, _ := json.Marshal(*r.Json)
-		return string(j), "application/json", true
+		j, err := marshalJson(*r.Json)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		return bytes.NewReader(j), int64(len(j)), "application/json", nil
 	}
-	if r.Multipart != nil {
-		return r.MultipartBody.String() + "\r\n--" + r.Multipart.Boundary() + "--\r\n", r.Multipart.FormDataContentType(), true
+	if len(r.MultipartFields) > 0 {
+		return prepareMultipartBody(r.MultipartFields, r.MultipartBoundary)
+	}
+	return nil, 0, "", nil
+}
+
+// bodyReaders returns the caller's readers prepareBody sends for r, an
+// attempt consumes them.
+func (r *Request) bodyReaders() []io.Reader {
+	switch {
+	case r.Body != "", r.BodyFile != "":
+		return nil
+	case r.BodyReader != nil:
+		return []io.Reader{r.BodyReader}
+	case r.Forms != nil, r.JsonBody != nil, r.Json != nil:
+		return nil
+	}
+	var readers []io.Reader
+	for _, field := range r.MultipartFields {
+		if field.Path == "" && field.Reader != nil {
+			readers = append(readers, field.Reader)
+		}
+	}
+	return readers
+}
+
+// bodyOffsets returns the offsets the body readers of r start at, false when
+// one of them is not an io.Seeker and the body can not be sent again.
+func (r *Request) bodyOffsets() ([]int64, bool) {
+	readers := r.bodyReaders()
+	offsets := make([]int64, len(readers))
+	for i, reader := range readers {
+		seeker, ok := reader.(io.Seeker)
+		if !ok {
+			return nil, false
+		}
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, false
+		}
+		offsets[i] = offset
+	}
+	return offsets, true
+}
+
+// rewindBody seeks the body readers of r back to offsets.
+func (r *Request) rewindBody(offsets []int64) error {
+	for i, reader := range r.bodyReaders() {
+		if _, err := reader.(io.Seeker).Seek(offsets[i], io.SeekStart); err != nil {
+			return err
+		}
 	}
-	return "", "", false
+	return nil
 }
 
 func Unmarshal(text []byte, v interface{}) error {
@@ -63,10 +143,10 @@ func StringInStringArray(value string, array []string) bool {
 	return false
 }
 
-func newDefaultHeaders() map[string]string {
-	headers := map[string]string{}
+func newDefaultHeaders() Header {
+	headers := Header{}
 	for k, v := range defaultHeaders {
-		headers[k] = v
+		headers.Set(k, v)
 	}
 	return headers
 }
@@ -0,0 +1,163 @@
+package tlsHttpClient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// JsonObject is a JSON object that keeps its members in insertion order when
+// marshalled, the way JSON.stringify does. Unmarshalling keeps the order of
+// the document, nested objects become *JsonObject.
+type JsonObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func NewJsonObject() *JsonObject {
+	return &JsonObject{values: map[string]any{}}
+}
+
+// Set sets key to value, an existing key keeps its position.
+func (o *JsonObject) Set(key string, value any) *JsonObject {
+	if o.values == nil {
+		o.values = map[string]any{}
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+	return o
+}
+
+func (o *JsonObject) Get(key string) (any, bool) {
+	value, ok := o.values[key]
+	return value, ok
+}
+
+func (o *JsonObject) Delete(key string) *JsonObject {
+	if _, ok := o.values[key]; !ok {
+		return o
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+	return o
+}
+
+// Keys returns the keys in order.
+func (o *JsonObject) Keys() []string {
+	return append([]string(nil), o.keys...)
+}
+
+func (o *JsonObject) Len() int {
+	return len(o.keys)
+}
+
+func (o *JsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := marshalJson(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := marshalJson(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o *JsonObject) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return errors.New("JsonObject: expected a JSON object")
+	}
+	*o = JsonObject{values: map[string]any{}}
+	return o.decodeMembers(decoder)
+}
+
+func (o *JsonObject) decodeMembers(decoder *json.Decoder) error {
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("JsonObject: unexpected token %v", token)
+		}
+		value, err := decodeJsonValue(decoder)
+		if err != nil {
+			return err
+		}
+		o.Set(key, value)
+	}
+	_, err := decoder.Token()
+	return err
+}
+
+func decodeJsonValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+	switch delim {
+	case '{':
+		object := NewJsonObject()
+		return object, object.decodeMembers(decoder)
+	case '[':
+		array := []any{}
+		for decoder.More() {
+			value, err := decodeJsonValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err := decoder.Token()
+		return array, err
+	default:
+		return nil, fmt.Errorf("JsonObject: unexpected token %v", delim)
+	}
+}
+
+// marshalJson encodes v like JSON.stringify, without escaping <, > and &.
+func marshalJson(v any) ([]byte, error) {
+	if raw, ok := v.(json.RawMessage); ok {
+		if !json.Valid(raw) {
+			return nil, errors.New("json.RawMessage body is not valid JSON")
+		}
+		return raw, nil
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,76 @@
+package tlsHttpClient
+
+// RequestMiddleware runs before every attempt, before the request is turned
+// into cycletls options, and may change anything on the request. Returning an
+// error fails the attempt.
+type RequestMiddleware func(c *Client, r *Request) error
+
+// ResponseMiddleware runs after every attempt that got a response. It may
+// inspect the response or return a different one, returning an error fails
+// the attempt.
+type ResponseMiddleware func(c *Client, resp *Response) (*Response, error)
+
+// ErrorHook is called once a request failed for good, after all retries.
+type ErrorHook func(r *Request, err error)
+
+// OnBeforeRequest appends m to the chain run before every attempt.
+func (c *Client) OnBeforeRequest(m RequestMiddleware) *Client {
+	c.beforeRequest = append(c.beforeRequest, m)
+	return c
+}
+
+// OnAfterResponse appends m to the chain run after every attempt.
+func (c *Client) OnAfterResponse(m ResponseMiddleware) *Client {
+	c.afterResponse = append(c.afterResponse, m)
+	return c
+}
+
+// OnError appends h to the hooks called when a request fails.
+func (c *Client) OnError(h ErrorHook) *Client {
+	c.errorHooks = append(c.errorHooks, h)
+	return c
+}
+
+// OnRetry appends h to the hooks called before every retry, after the hooks
+// of the retry policy.
+func (c *Client) OnRetry(h RetryHookFunc) *Client {
+	c.retryHooks = append(c.retryHooks, h)
+	return c
+}
+
+func (c *Client) runBeforeRequest(r *Request) error {
+	for _, m := range c.beforeRequest {
+		if err := m(c, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) runAfterResponse(resp *Response) (*Response, error) {
+	for _, m := range c.afterResponse {
+		next, err := m(c, resp)
+		if err != nil {
+			return resp, err
+		}
+		if next != nil {
+			resp = next
+		}
+	}
+	return resp, nil
+}
+
+func (c *Client) runErrorHooks(r *Request, err error) {
+	for _, h := range c.errorHooks {
+		h(r, err)
+	}
+}
+
+func (c *Client) runRetryHooks(policy *RetryPolicy, attempt int, resp *Response, err error) {
+	for _, h := range policy.Hooks {
+		h(attempt, resp, err)
+	}
+	for _, h := range c.retryHooks {
+		h(attempt, resp, err)
+	}
+}
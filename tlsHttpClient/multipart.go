@@ -0,0 +1,267 @@
+package tlsHttpClient
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	http "github.com/Danny-Dasilva/fhttp"
+)
+
+// MultipartField is one part of a multipart/form-data body. The content is
+// Path when set, then Reader, then Value.
+type MultipartField struct {
+	Name     string
+	FileName string
+	// ContentType is sniffed from FileName or the content when empty, plain
+	// value fields without a file name get none
+	ContentType string
+
+	Value string
+	// Reader is streamed as the part content, Length is its size or <= 0 when
+	// unknown. As with Request.SetBodyReader, a Reader that is not an
+	// io.Seeker keeps the request from being retried.
+	Reader io.Reader
+	Length int64
+	// Path is a file opened when the body is sent, on every attempt
+	Path string
+}
+
+type multipartPart struct {
+	header textproto.MIMEHeader
+	path   string
+	reader io.Reader
+	length int64
+}
+
+func (r *Request) addMultipartField(field *MultipartField) *Request {
+	r.MultipartFields = append(r.MultipartFields, field)
+	return r
+}
+
+// SetMultipartField appends a part streamed from reader.
+func (r *Request) SetMultipartField(param, fileName, contentType string, reader io.Reader) *Request {
+	return r.addMultipartField(&MultipartField{Name: param, FileName: fileName, ContentType: contentType, Reader: reader})
+}
+
+// SetMultipartFile appends the file at path as a part named param, the file
+// name is the base of path.
+func (r *Request) SetMultipartFile(param, path string) *Request {
+	return r.addMultipartField(&MultipartField{Name: param, FileName: filepath.Base(path), Path: path})
+}
+
+// SetMultipartValue appends a plain value part.
+func (r *Request) SetMultipartValue(param, value string) *Request {
+	return r.addMultipartField(&MultipartField{Name: param, Value: value})
+}
+
+// SetMultipartFields appends fields in order.
+func (r *Request) SetMultipartFields(fields ...*MultipartField) *Request {
+	for _, field := range fields {
+		r.addMultipartField(field)
+	}
+	return r
+}
+
+// SetMultipartFormData appends value parts in order of their keys, use
+// SetMultipartValue when the order matters.
+func (r *Request) SetMultipartFormData(data map[string]string) *Request {
+	for _, param := range paramsFromMap(data) {
+		r.SetMultipartValue(param.Key, param.Value)
+	}
+	return r
+}
+
+func (r *Request) SetMultipartBoundary(boundary string) *Request {
+	if err := multipart.NewWriter(io.Discard).SetBoundary(boundary); err != nil {
+		r.setError(err)
+		return r
+	}
+	r.MultipartBoundary = boundary
+	return r
+}
+
+// prepareMultipartBody resolves fields into parts and returns a body that
+// writes them as it is read, with its length when every part size is known.
+func prepareMultipartBody(fields []*MultipartField, boundary string) (io.Reader, int64, string, error) {
+	if boundary == "" {
+		boundary = multipart.NewWriter(io.Discard).Boundary()
+	}
+
+	parts := make([]multipartPart, 0, len(fields))
+	for _, field := range fields {
+		part, err := resolveMultipartField(field)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		parts = append(parts, part)
+	}
+
+	// the framing is measured by writing it without content
+	counter := &countingWriter{}
+	writer := multipart.NewWriter(counter)
+	_ = writer.SetBoundary(boundary)
+	length := int64(0)
+	for _, part := range parts {
+		if _, err := writer.CreatePart(part.header); err != nil {
+			return nil, 0, "", err
+		}
+		if length >= 0 && part.length >= 0 {
+			length += part.length
+		} else {
+			length = -1
+		}
+	}
+	_ = writer.Close()
+	if length >= 0 {
+		length += counter.n
+	}
+
+	body := &multipartReader{parts: parts, boundary: boundary}
+	return body, length, "multipart/form-data; boundary=" + boundary, nil
+}
+
+func resolveMultipartField(field *MultipartField) (multipartPart, error) {
+	part := multipartPart{}
+	contentType := field.ContentType
+	fileName := field.FileName
+
+	switch {
+	case field.Path != "":
+		file, err := os.Open(field.Path)
+		if err != nil {
+			return part, err
+		}
+		defer file.Close()
+		stat, err := file.Stat()
+		if err != nil {
+			return part, err
+		}
+		if fileName == "" {
+			fileName = filepath.Base(field.Path)
+		}
+		if contentType == "" {
+			contentType = sniffContentType(fileName, bufio.NewReader(file))
+		}
+		part.path = field.Path
+		part.length = stat.Size()
+	case field.Reader != nil:
+		part.reader = field.Reader
+		part.length = readerLength(field.Reader, field.Length)
+		if contentType == "" && fileName != "" {
+			buffered := bufio.NewReader(field.Reader)
+			contentType = sniffContentType(fileName, buffered)
+			part.reader = buffered
+		}
+	default:
+		part.reader = strings.NewReader(field.Value)
+		part.length = int64(len(field.Value))
+		if contentType == "" && fileName != "" {
+			contentType = sniffContentType(fileName, bufio.NewReader(strings.NewReader(field.Value)))
+		}
+	}
+
+	part.header = NewMultipartFieldHeader(field.Name, fileName, contentType)
+	return part, nil
+}
+
+// sniffContentType guesses from the file extension, then from the first 512
+// bytes of content without consuming them.
+func sniffContentType(fileName string, content *bufio.Reader) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(fileName)); contentType != "" {
+		return contentType
+	}
+	head, _ := content.Peek(512)
+	if len(head) == 0 {
+		return "application/octet-stream"
+	}
+	return http.DetectContentType(head)
+}
+
+func readerLength(reader io.Reader, length int64) int64 {
+	if length > 0 {
+		return length
+	}
+	switch v := reader.(type) {
+	case *bytes.Buffer:
+		return int64(v.Len())
+	case *bytes.Reader:
+		return int64(v.Len())
+	case *strings.Reader:
+		return int64(v.Len())
+	}
+	return -1
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// multipartReader writes the parts into a pipe from the first Read on, so
+// nothing is opened or buffered for a body that is never sent.
+type multipartReader struct {
+	parts    []multipartPart
+	boundary string
+
+	once   sync.Once
+	reader *io.PipeReader
+}
+
+func (m *multipartReader) start() {
+	pr, pw := io.Pipe()
+	m.reader = pr
+	go func() {
+		writer := multipart.NewWriter(pw)
+		_ = writer.SetBoundary(m.boundary)
+		for _, part := range m.parts {
+			if err := writeMultipartPart(writer, part); err != nil {
+				_ = pw.CloseWithError(err)
+				return
+			}
+		}
+		_ = pw.CloseWithError(writer.Close())
+	}()
+}
+
+func writeMultipartPart(writer *multipart.Writer, part multipartPart) error {
+	w, err := writer.CreatePart(part.header)
+	if err != nil {
+		return err
+	}
+	reader := part.reader
+	if part.path != "" {
+		file, err := os.Open(part.path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader = file
+	}
+	_, err = io.Copy(w, reader)
+	return err
+}
+
+func (m *multipartReader) Read(p []byte) (int, error) {
+	m.once.Do(m.start)
+	return m.reader.Read(p)
+}
+
+func (m *multipartReader) Close() error {
+	m.once.Do(func() {})
+	if m.reader != nil {
+		return m.reader.Close()
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package tlsHttpClient
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Param is a single key value pair of a query string or a form.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params is an ordered list of pairs in which keys may repeat, used for
+// query strings and urlencoded forms. Unlike url.Values it keeps insertion
+// order when encoded.
+type Params []Param
+
+// Add appends a pair, keeping values already set for key.
+func (p *Params) Add(key, value string) {
+	*p = append(*p, Param{Key: key, Value: value})
+}
+
+// Set replaces the values of key with value, at the position of its first
+// occurrence or at the end when key is new.
+func (p *Params) Set(key, value string) {
+	p.replace(key, []string{value})
+}
+
+// Get returns the first value of key.
+func (p Params) Get(key string) string {
+	for _, param := range p {
+		if param.Key == key {
+			return param.Value
+		}
+	}
+	return ""
+}
+
+// Values returns all values of key in order.
+func (p Params) Values(key string) []string {
+	var values []string
+	for _, param := range p {
+		if param.Key == key {
+			values = append(values, param.Value)
+		}
+	}
+	return values
+}
+
+func (p Params) Has(key string) bool {
+	for _, param := range p {
+		if param.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Del removes every value of key.
+func (p *Params) Del(key string) {
+	kept := (*p)[:0]
+	for _, param := range *p {
+		if param.Key != key {
+			kept = append(kept, param)
+		}
+	}
+	*p = kept
+}
+
+// Merge replaces the values of every key of other with its values in other,
+// keys new to p are appended in the order of other.
+func (p *Params) Merge(other Params) {
+	seen := map[string]bool{}
+	for _, param := range other {
+		if seen[param.Key] {
+			continue
+		}
+		seen[param.Key] = true
+		p.replace(param.Key, other.Values(param.Key))
+	}
+}
+
+func (p *Params) replace(key string, values []string) {
+	index := -1
+	for i, param := range *p {
+		if param.Key == key {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		for _, v := range values {
+			p.Add(key, v)
+		}
+		return
+	}
+	replaced := make(Params, 0, len(*p)+len(values))
+	replaced = append(replaced, (*p)[:index]...)
+	for _, v := range values {
+		replaced = append(replaced, Param{Key: key, Value: v})
+	}
+	for _, param := range (*p)[index:] {
+		if param.Key != key {
+			replaced = append(replaced, param)
+		}
+	}
+	*p = replaced
+}
+
+// Encode returns the pairs in "key=value&key=value" form, in order.
+func (p Params) Encode() string {
+	var buf strings.Builder
+	for i, param := range p {
+		if i > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(url.QueryEscape(param.Key))
+		buf.WriteByte('=')
+		buf.WriteString(url.QueryEscape(param.Value))
+	}
+	return buf.String()
+}
+
+// ParseParams parses a query string keeping the order and repeats of keys.
+func ParseParams(query string) (Params, error) {
+	var params Params
+	var firstErr error
+	for query != "" {
+		var pair string
+		pair, query, _ = strings.Cut(query, "&")
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(key)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		params.Add(key, value)
+	}
+	return params, firstErr
+}
+
+// paramsFromMap converts m sorted by key, maps have no order of their own.
+func paramsFromMap(m map[string]string) Params {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	params := make(Params, 0, len(keys))
+	for _, k := range keys {
+		params.Add(k, m[k])
+	}
+	return params
+}
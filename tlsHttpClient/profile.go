@@ -0,0 +1,264 @@
+package tlsHttpClient
+
+import (
+	"fmt"
+
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls"
+)
+
+// Profile bundles what a browser's requests are recognised by: the TLS
+// ClientHello, the user agent and client hints, the default headers and the
+// order of headers and HTTP/2 pseudo headers. Browser is the browser family,
+// it decides GREASE in the ClientHello. HTTP2 is the Akamai fingerprint of
+// the HTTP/2 connection, the preset of Browser when empty. Apply one with
+// Client.SetProfile or Request.SetProfile.
+type Profile struct {
+	Name      string
+	Browser   string
+	JA3       string
+	UserAgent string
+	// ClientHints are the sec-ch-ua headers Chromium browsers send
+	ClientHints Header
+	// Headers are the headers of a top level navigation
+	Headers      Header
+	HeaderOrder  []string
+	PHeaderOrder []string
+	HTTP2        string
+}
+
+const (
+	firefoxJA3 = "771,4865-4867-4866-49195-49199-52393-52392-49196-49200-49162-49161-49171-49172-156-157-47-53,0-23-65281-10-11-35-16-5-34-51-43-13-45-28-21,29-23-24-25-256-257,0"
+	safariJA3  = "771,4865-4866-4867-49196-49195-52393-49200-49199-52392-49162-49161-49172-49171-157-156-53-47-49160-49170-10,0-23-65281-10-11-16-5-13-18-51-45-43-27-21,29-23-24-25,0"
+
+	chrome105HTTP2 = "1:65536;3:1000;4:6291456;6:262144|15663105|0|m,a,s,p"
+	chrome106HTTP2 = "1:65536;2:0;3:1000;4:6291456;6:262144|15663105|0|m,a,s,p"
+	firefoxHTTP2   = "1:65536;4:131072;5:16384|12517377|3:0:0:201,5:0:0:101,7:0:0:1,9:0:7:1,11:0:3:1,13:0:0:241|m,p,a,s"
+	safari16HTTP2  = "4:4194304;3:100|10485760|0|m,s,p,a"
+	safariHTTP2    = "2:0;4:4194304;3:100|10485760|0|m,s,p,a"
+)
+
+var (
+	chromiumHeaderOrder = []string{
+		"host", "connection", "content-length", "cache-control",
+		"sec-ch-ua", "sec-ch-ua-mobile", "sec-ch-ua-platform",
+		"upgrade-insecure-requests", "origin", "content-type", "user-agent", "accept",
+		"sec-fetch-site", "sec-fetch-mode", "sec-fetch-user", "sec-fetch-dest",
+		"referer", "accept-encoding", "accept-language", "cookie",
+	}
+	firefoxHeaderOrder = []string{
+		"host", "user-agent", "accept", "accept-language", "accept-encoding",
+		"content-type", "content-length", "origin", "connection", "referer", "cookie",
+		"upgrade-insecure-requests", "sec-fetch-dest", "sec-fetch-mode", "sec-fetch-site", "sec-fetch-user",
+		"te",
+	}
+	safariHeaderOrder = []string{
+		"host", "content-type", "accept", "sec-fetch-site", "cookie", "sec-fetch-dest",
+		"content-length", "accept-language", "sec-fetch-mode", "origin", "user-agent",
+		"referer", "accept-encoding", "connection",
+	}
+)
+
+// Browser profiles, only versions whose ClientHello CycleTLS reproduces are
+// listed. Chromium browsers shuffle their extensions since version 110, and
+// later Chrome and Firefox versions send encrypted client hello, a fixed JA3
+// string can not describe either. Chromium 105 to 109 share the ClientHello
+// of ChromeJA3, from 106 on they disable server push.
+var (
+	ProfileChrome105 = chromiumProfile("chrome-105-windows", ChromeUserAgent,
+		`"Google Chrome";v="105", "Not)A;Brand";v="8", "Chromium";v="105"`, `"Windows"`, false, chrome105HTTP2)
+	ProfileChrome109 = chromiumProfile("chrome-109-windows",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Safari/537.36",
+		`"Not_A Brand";v="99", "Google Chrome";v="109", "Chromium";v="109"`, `"Windows"`, false, chrome106HTTP2)
+	ProfileEdge105 = chromiumProfile("edge-105-windows",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/105.0.0.0 Safari/537.36 Edg/105.0.1343.53",
+		`"Microsoft Edge";v="105", " Not;A Brand";v="99", "Chromium";v="105"`, `"Windows"`, false, chrome105HTTP2)
+	ProfileEdge109 = chromiumProfile("edge-109-windows",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Safari/537.36 Edg/109.0.1518.78",
+		`"Not_A Brand";v="99", "Microsoft Edge";v="109", "Chromium";v="109"`, `"Windows"`, false, chrome106HTTP2)
+	ProfileChromeAndroid109 = chromiumProfile("chrome-109-android",
+		"Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Mobile Safari/537.36",
+		`"Not_A Brand";v="99", "Google Chrome";v="109", "Chromium";v="109"`, `"Android"`, true, chrome106HTTP2)
+
+	ProfileFirefox115 = firefoxProfile("firefox-115-windows",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:109.0) Gecko/20100101 Firefox/115.0")
+
+	ProfileSafari16 = safariProfile("safari-16-macos",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Safari/605.1.15", safari16HTTP2)
+	ProfileSafari17 = safariProfile("safari-17-macos",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15", safariHTTP2)
+	ProfileSafariIOS17 = safariProfile("safari-17-ios",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1", safariHTTP2)
+)
+
+// Profiles lists the built-in profiles.
+var Profiles = []Profile{
+	ProfileChrome105, ProfileChrome109, ProfileEdge105, ProfileEdge109, ProfileChromeAndroid109,
+	ProfileFirefox115,
+	ProfileSafari16, ProfileSafari17, ProfileSafariIOS17,
+}
+
+// ProfileByName returns the built-in profile called name.
+func ProfileByName(name string) (Profile, bool) {
+	for _, profile := range Profiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return Profile{}, false
+}
+
+func chromiumProfile(name, userAgent, brands, platform string, mobile bool, http2 string) Profile {
+	clientHints := Header{}
+	clientHints.Set("Sec-Ch-Ua", brands)
+	clientHints.Set("Sec-Ch-Ua-Mobile", "?0")
+	if mobile {
+		clientHints.Set("Sec-Ch-Ua-Mobile", "?1")
+	}
+	clientHints.Set("Sec-Ch-Ua-Platform", platform)
+
+	headers := Header{}
+	headers.Set("Upgrade-Insecure-Requests", "1")
+	headers.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7")
+	headers.Set("Sec-Fetch-Site", "none")
+	headers.Set("Sec-Fetch-Mode", "navigate")
+	headers.Set("Sec-Fetch-User", "?1")
+	headers.Set("Sec-Fetch-Dest", "document")
+	headers.Set("Accept-Encoding", "gzip, deflate, br")
+	headers.Set("Accept-Language", "en-US,en;q=0.9")
+
+	return Profile{
+		Name:         name,
+		Browser:      cycletls.BrowserChrome,
+		JA3:          ChromeJA3,
+		UserAgent:    userAgent,
+		ClientHints:  clientHints,
+		Headers:      headers,
+		HeaderOrder:  chromiumHeaderOrder,
+		PHeaderOrder: []string{":method", ":authority", ":scheme", ":path"},
+		HTTP2:        http2,
+	}
+}
+
+func firefoxProfile(name, userAgent string) Profile {
+	headers := Header{}
+	headers.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8")
+	headers.Set("Accept-Language", "en-US,en;q=0.5")
+	headers.Set("Accept-Encoding", "gzip, deflate, br")
+	headers.Set("Upgrade-Insecure-Requests", "1")
+	headers.Set("Sec-Fetch-Dest", "document")
+	headers.Set("Sec-Fetch-Mode", "navigate")
+	headers.Set("Sec-Fetch-Site", "none")
+	headers.Set("Sec-Fetch-User", "?1")
+	headers.Set("Te", "trailers")
+
+	return Profile{
+		Name:         name,
+		Browser:      cycletls.BrowserFirefox,
+		JA3:          firefoxJA3,
+		UserAgent:    userAgent,
+		Headers:      headers,
+		HeaderOrder:  firefoxHeaderOrder,
+		PHeaderOrder: []string{":method", ":path", ":authority", ":scheme"},
+		HTTP2:        firefoxHTTP2,
+	}
+}
+
+func safariProfile(name, userAgent, http2 string) Profile {
+	headers := Header{}
+	headers.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	headers.Set("Sec-Fetch-Site", "none")
+	headers.Set("Sec-Fetch-Dest", "document")
+	headers.Set("Accept-Language", "en-US,en;q=0.9")
+	headers.Set("Sec-Fetch-Mode", "navigate")
+	headers.Set("Accept-Encoding", "gzip, deflate, br")
+
+	return Profile{
+		Name:         name,
+		Browser:      cycletls.BrowserSafari,
+		JA3:          safariJA3,
+		UserAgent:    userAgent,
+		Headers:      headers,
+		HeaderOrder:  safariHeaderOrder,
+		PHeaderOrder: []string{":method", ":scheme", ":path", ":authority"},
+		HTTP2:        http2,
+	}
+}
+
+// headers returns the headers the profile sends: its defaults, client hints
+// and user agent.
+func (p Profile) headers() Header {
+	headers := p.Headers.Clone()
+	if headers == nil {
+		headers = Header{}
+	}
+	for k, v := range p.ClientHints {
+		headers[k] = append([]string(nil), v...)
+	}
+	headers.Set("User-Agent", p.UserAgent)
+	return headers
+}
+
+func (p Profile) validate() error {
+	if _, err := cycletls.ParseJA3(p.JA3); err != nil {
+		return fmt.Errorf("profile %s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// http2Settings returns the parsed HTTP2 of the profile, nil when it has
+// none.
+func (p Profile) http2Settings() (*cycletls.HTTP2Settings, error) {
+	if p.HTTP2 == "" {
+		return nil, nil
+	}
+	settings, err := cycletls.ParseHTTP2Settings(p.HTTP2)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %w", p.Name, err)
+	}
+	return settings, nil
+}
+
+// SetProfile makes the client impersonate the browser of p. It replaces the
+// fingerprint, the default headers and the header orders of the client.
+func (c *Client) SetProfile(p Profile) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	settings, err := p.http2Settings()
+	if err != nil {
+		return err
+	}
+	c.Ja3 = p.JA3
+	c.Ja4 = ""
+	c.Browser = p.Browser
+	c.HTTP2Settings = settings
+	c.Props.Headers = p.headers()
+	c.Props.HeaderOrder = append([]string(nil), p.HeaderOrder...)
+	c.Props.PHeaderOrder = append([]string(nil), p.PHeaderOrder...)
+	return nil
+}
+
+// SetProfile makes the request impersonate the browser of p. The headers of
+// p take precedence over those of the client, other client headers are
+// still sent.
+func (r *Request) SetProfile(p Profile) *Request {
+	if err := p.validate(); err != nil {
+		r.setError(err)
+		return r
+	}
+	settings, err := p.http2Settings()
+	if err != nil {
+		r.setError(err)
+		return r
+	}
+	r.Ja3 = p.JA3
+	r.Ja4 = ""
+	r.Browser = p.Browser
+	r.HTTP2Settings = settings
+	for k, v := range p.headers() {
+		r.Headers[k] = v
+	}
+	r.HeaderOrder = append([]string(nil), p.HeaderOrder...)
+	r.PHeaderOrder = append([]string(nil), p.PHeaderOrder...)
+	return r
+}
@@ -1,13 +1,12 @@
 package tlsHttpClient
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"log"
-	"mime/multipart"
-	"net/url"
 	"strings"
+
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls"
 )
 
 type Request struct {
@@ -16,24 +15,69 @@ type Request struct {
 	Method string
 
 	URL        string
-	QueryParam map[string]string
-
-	Headers map[string]string
-
-	Body          string
-	Forms         *map[string]string
-	Json          *map[string]any
-	Multipart     *multipart.Writer
-	MultipartBody *bytes.Buffer
+	QueryParam Params
+	PathParams map[string]string
+
+	Headers      Header
+	HeaderOrder  []string
+	PHeaderOrder []string
+	Cookies      []Cookie
+
+	Body              string
+	BodyReader        io.Reader
+	BodyLength        int64
+	BodyFile          string
+	Forms             Params
+	Json              *map[string]any
+	JsonBody          any
+	MultipartFields   []*MultipartField
+	MultipartBoundary string
 
 	DisableRedirect bool
 
 	SetContentTypeDirectly bool
 
-	Proxy *Proxy
+	DoNotParseResponse bool
+	DownloadProgress   ProgressFunc
+
+	Proxy   *Proxy
+	Ja3     string
+	Ja4     string
+	Browser string // browser family, see cycletls.Options.Browser
+
+	HTTP2Settings *cycletls.HTTP2Settings
 
-	Attempts int
-	Timeout  int
+	Attempts    int
+	Timeout     int
+	RetryPolicy *RetryPolicy
+
+	Result any
+	Error  any
+
+	ctx context.Context
+	err error
+}
+
+// setError records an error made while building the request, Execute
+// returns the first one.
+func (r *Request) setError(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Context returns the request context, context.Background when none was set.
+func (r *Request) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// SetContext binds the request to ctx, cancelling ctx aborts the request in flight.
+func (r *Request) SetContext(ctx context.Context) *Request {
+	r.ctx = ctx
+	return r
 }
 
 func (r *Request) ExportProxy() string {
@@ -43,66 +87,81 @@ func (r *Request) ExportProxy() string {
 	return ""
 }
 
-func (r *Request) ExportHeaders() (map[string]string, string) {
-	headers := make(map[string]string)
-
-	for k, v := range r.Client.Props.Headers {
-		headers[k] = v
+// ExportHeaders merges the client and request headers, the request values
+// of a header replace all client values of it.
+func (r *Request) ExportHeaders() (Header, string) {
+	headers := r.Client.Props.Headers.Clone()
+	if headers == nil {
+		headers = Header{}
 	}
 	for k, v := range r.Headers {
-		headers[k] = v
+		headers[k] = append([]string(nil), v...)
 	}
+	r.exportCookies(headers)
 
 	userAgent := ChromeUserAgent
-	for k, v := range headers {
-		if strings.ToLower(k) == "user-agent" {
-			userAgent = v
-			break
-		}
+	if v := headers.Get("User-Agent"); v != "" {
+		userAgent = v
 	}
 
 	return headers, userAgent
 }
 
-func (r *Request) ExportUrl() string {
-	result := r.URL
-	if len(r.QueryParam) > 0 || len(r.Client.Props.QueryParam) > 0 {
-		if strings.Contains(result, "?") {
-			if !strings.HasSuffix(result, "&") {
-				result += "&"
-			}
-		} else {
-			result += "?"
-		}
-
-		params := url.Values{}
-		if r.Client.Props.QueryParam != nil {
-			for k, v := range r.Client.Props.QueryParam {
-				params.Set(k, v)
-			}
-		}
-		if r.QueryParam != nil {
-			for k, v := range r.QueryParam {
-				params.Set(k, v)
-			}
-		}
-		result += params.Encode()
+// ExportUrl builds the final URL, see Client.SetBaseURL and SetPathParam.
+// Query parameters of the request replace those of the client, which replace
+// those already in the URL.
+func (r *Request) ExportUrl() (string, error) {
+	pathParams := make(map[string]string, len(r.Client.Props.PathParams)+len(r.PathParams))
+	for k, v := range r.Client.Props.PathParams {
+		pathParams[k] = v
 	}
-	return result
+	for k, v := range r.PathParams {
+		pathParams[k] = v
+	}
+
+	var query Params
+	query.Merge(r.Client.Props.QueryParam)
+	query.Merge(r.QueryParam)
+
+	return buildURL(r.Client.BaseURL, r.URL, pathParams, query)
 }
 
-func (r *Request) ExportBody() string {
-	body, contentType, setBody := prepareBody(r)
-	if setBody {
+// ExportBody returns the body reader with its length, -1 when the length is
+// unknown and the body has to be sent chunked.
+func (r *Request) ExportBody() (io.Reader, int64, error) {
+	body, length, contentType, err := prepareBody(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	if body != nil {
 		if !r.SetContentTypeDirectly {
 			r.SetHeader("Content-Type", contentType)
 		}
 	}
-	return body
+	return body, length, nil
 }
 
 func (r *Request) SetHeader(header, value string) *Request {
-	r.Headers[header] = value
+	r.Headers.Set(header, value)
+	return r
+}
+
+// SetHeaderOrder overrides the client header order for this request.
+func (r *Request) SetHeaderOrder(order ...string) *Request {
+	r.HeaderOrder = order
+	return r
+}
+
+// SetPseudoHeaderOrder overrides the client pseudo header order for this request.
+func (r *Request) SetPseudoHeaderOrder(order ...string) *Request {
+	r.PHeaderOrder = order
+	return r
+}
+
+// AddHeader appends value to the values already set for header, so the
+// header is sent once per value.
+func (r *Request) AddHeader(header, value string) *Request {
+	r.Headers.Add(header, value)
 	return r
 }
 
@@ -113,78 +172,103 @@ func (r *Request) SetHeaders(headers map[string]string) *Request {
 	return r
 }
 
+// SetQueryParam sets param to value, replacing values set before.
 func (r *Request) SetQueryParam(param, value string) *Request {
-	if r.QueryParam == nil {
-		r.QueryParam = map[string]string{}
-	}
-	r.QueryParam[param] = value
+	r.QueryParam.Set(param, value)
 	return r
 }
 
+// AddQueryParam appends a value for param, so the parameter repeats.
+func (r *Request) AddQueryParam(param, value string) *Request {
+	r.QueryParam.Add(param, value)
+	return r
+}
+
+// SetQueryParams sets every pair of params, in order of their keys.
 func (r *Request) SetQueryParams(params map[string]string) *Request {
-	for p, v := range params {
-		r.SetQueryParam(p, v)
-	}
+	r.QueryParam.Merge(paramsFromMap(params))
 	return r
 }
+
+// SetQueryString sets the parameters of query, keeping their order and
+// repeated keys.
 func (r *Request) SetQueryString(query string) *Request {
-	params, err := url.ParseQuery(strings.TrimSpace(query))
+	params, err := ParseParams(strings.TrimPrefix(strings.TrimSpace(query), "?"))
 	if err == nil {
-		for p, v := range params {
-			for _, pv := range v {
-				r.SetQueryParam(p, pv)
-			}
-		}
+		r.QueryParam.Merge(params)
 	}
 	return r
 }
 
-func (r *Request) SetFormData(data map[string]string) *Request {
-	if r.Forms == nil {
-		r.Forms = &map[string]string{}
-	}
-	for k, v := range data {
-		(*r.Forms)[k] = v
+// SetPathParam replaces {param} in the request URL with the escaped value.
+func (r *Request) SetPathParam(param, value string) *Request {
+	if r.PathParams == nil {
+		r.PathParams = map[string]string{}
 	}
+	r.PathParams[param] = value
 	return r
 }
-func (r *Request) SetBody(body string) *Request {
-	r.Body = body
+
+func (r *Request) SetPathParams(params map[string]string) *Request {
+	for p, v := range params {
+		r.SetPathParam(p, v)
+	}
 	return r
 }
 
-func (r *Request) SetMultipartField(param, fileName, contentType string, reader io.Reader) *Request {
-	if r.Multipart == nil {
-		r.MultipartBody = &bytes.Buffer{}
-		r.Multipart = multipart.NewWriter(r.MultipartBody)
+// SetFormData sets the form fields of data in order of their keys, use
+// SetFormParams or AddFormValue when the field order matters.
+func (r *Request) SetFormData(data map[string]string) *Request {
+	return r.SetFormParams(paramsFromMap(data))
+}
+
+// SetFormParams sets the fields of params, keeping their order and repeated
+// keys. Fields set before keep their position and get the new values.
+func (r *Request) SetFormParams(params Params) *Request {
+	if r.Forms == nil {
+		r.Forms = Params{}
 	}
-	header := NewMultipartFieldHeader(param, fileName, contentType)
-	part, _ := r.Multipart.CreatePart(header)
-	_, _ = io.Copy(part, reader)
+	r.Forms.Merge(params)
 	return r
 }
 
-func (r *Request) SetMultipartFormData(data map[string]string) *Request {
-	if r.Multipart == nil {
-		r.MultipartBody = &bytes.Buffer{}
-		r.Multipart = multipart.NewWriter(r.MultipartBody)
+// SetFormValue sets field to value, replacing values set before.
+func (r *Request) SetFormValue(field, value string) *Request {
+	if r.Forms == nil {
+		r.Forms = Params{}
 	}
-	for k, v := range data {
-		_ = r.Multipart.WriteField(k, v)
+	r.Forms.Set(field, value)
+	return r
+}
+
+// AddFormValue appends a value for field, so the field repeats.
+func (r *Request) AddFormValue(field, value string) *Request {
+	if r.Forms == nil {
+		r.Forms = Params{}
 	}
+	r.Forms.Add(field, value)
+	return r
+}
+func (r *Request) SetBody(body string) *Request {
+	r.Body = body
+	return r
+}
 
+// SetBodyReader streams reader as the request body. Pass a length <= 0 when
+// it is unknown, the body is then sent with chunked transfer encoding.
+// A reader that is an io.Seeker is rewound for every retry, any other is
+// consumed by the first attempt and the request is not retried. The reader
+// is never closed, that is left to the caller.
+func (r *Request) SetBodyReader(reader io.Reader, length int64) *Request {
+	r.BodyReader = reader
+	r.BodyLength = length
 	return r
 }
 
-func (r *Request) SetMultipartBoundary(boundary string) *Request {
-	if r.Multipart == nil {
-		r.MultipartBody = &bytes.Buffer{}
-		r.Multipart = multipart.NewWriter(r.MultipartBody)
-	}
-	err := r.Multipart.SetBoundary(boundary)
-	if err != nil {
-		log.Println("SetMultipartBoundary:", err)
-	}
+// SetBodyFile streams the file at path as the request body. The file is
+// opened on every attempt, so retries resend it from the start.
+func (r *Request) SetBodyFile(path string) *Request {
+	r.BodyFile = path
 	return r
 }
 
@@ -198,12 +282,95 @@ func (r *Request) SetJsonData(data map[string]any) *Request {
 	return r
 }
 
+// SetJsonBody sets v as the JSON body. Use a *JsonObject to control the key
+// order, a json.RawMessage is sent as is and anything else goes through
+// encoding/json. It takes precedence over SetJsonData.
+func (r *Request) SetJsonBody(v any) *Request {
+	r.JsonBody = v
+	return r
+}
+
 func (r *Request) SetContentType(contentType string) *Request {
 	r.SetContentTypeDirectly = true
 	r.SetHeader("Content-Type", contentType)
 	return r
 }
 
+// SetDoNotParseResponse makes the response body stream through Response.Body
+// instead of being read into Response.Bytes, the caller must close it. The
+// timeout then limits waiting for the headers and for every read of the body,
+// not the whole transfer.
+func (r *Request) SetDoNotParseResponse(value bool) *Request {
+	r.DoNotParseResponse = value
+	return r
+}
+
+// SetResult sets a pointer the body of a successful response is decoded into.
+func (r *Request) SetResult(v any) *Request {
+	r.Result = v
+	return r
+}
+
+// SetError sets a pointer the body of a 4xx or 5xx response is decoded into.
+func (r *Request) SetError(v any) *Request {
+	r.Error = v
+	return r
+}
+
+func (r *Request) SetAttempts(attempts int) *Request {
+	r.Attempts = attempts
+	return r
+}
+
+// SetRetryPolicy overrides the client retry policy for this request.
+func (r *Request) SetRetryPolicy(policy *RetryPolicy) *Request {
+	r.RetryPolicy = policy
+	return r
+}
+
+// SetTimeout overrides the client timeout for this request, in seconds.
+// The timeout covers reading the body unless it is streamed, see
+// SetDoNotParseResponse, a negative value disables it.
+func (r *Request) SetTimeout(timeout int) *Request {
+	r.Timeout = timeout
+	return r
+}
+
+// SetJA3 overrides the TLS fingerprint of the client for this request, an
+// invalid ja3 is returned by Execute.
+func (r *Request) SetJA3(ja3 string) *Request {
+	if _, err := cycletls.ParseJA3(ja3); err != nil {
+		r.setError(err)
+		return r
+	}
+	r.Ja3 = ja3
+	r.Ja4 = ""
+	return r
+}
+
+// SetJA4 overrides the TLS fingerprint of the client for this request with
+// a JA4_r string, an invalid one is returned by Execute.
+func (r *Request) SetJA4(ja4r string) *Request {
+	if _, err := cycletls.ParseJA4(ja4r); err != nil {
+		r.setError(err)
+		return r
+	}
+	r.Ja4 = ja4r
+	return r
+}
+
+// SetHTTP2Settings overrides the HTTP/2 fingerprint of the client for this
+// request with an Akamai fingerprint, an invalid one is returned by Execute.
+func (r *Request) SetHTTP2Settings(akamai string) *Request {
+	settings, err := cycletls.ParseHTTP2Settings(akamai)
+	if err != nil {
+		r.setError(err)
+		return r
+	}
+	r.HTTP2Settings = settings
+	return r
+}
+
 func (r *Request) SetProxy(proxy Proxy) *Request {
 	r.Proxy = &proxy
 	return r
@@ -213,27 +380,99 @@ func (r *Request) Execute(method, url string) (*Response, error) {
 	r.Method = method
 	r.URL = url
 
-	if r.Multipart != nil && !(method == MethodPost || method == MethodPut || method == MethodPatch) {
-		return nil, fmt.Errorf("multipart content is not allowed in HTTP verb [%v]", method)
+	if r.err != nil {
+		r.Client.runErrorHooks(r, r.err)
+		return nil, r.err
+	}
+	if len(r.MultipartFields) > 0 && !(method == MethodPost || method == MethodPut || method == MethodPatch) {
+		err := fmt.Errorf("multipart content is not allowed in HTTP verb [%v]", method)
+		r.Client.runErrorHooks(r, err)
+		return nil, err
 	}
 
 	var err error
 	var resp *Response
 
-	if r.Client.Attempts == 0 {
+	if r.Attempts < 1 {
 		r.Attempts = 1
 	}
+	policy := r.retryPolicy()
+	offsets, rewindable := r.bodyOffsets()
 
-	for i := 0; i < r.Attempts; i++ {
+	for attempt := 1; ; attempt++ {
 		resp, err = r.Client.execute(r)
-		if err == nil {
+		if resp != nil {
+			resp.Attempts = attempt
+		}
+		if err != nil {
+			if ctxErr := r.Context().Err(); ctxErr != nil {
+				r.Client.runErrorHooks(r, ctxErr)
+				return resp, ctxErr
+			}
+		}
+		if attempt >= r.Attempts || !rewindable || !policy.shouldRetry(r, resp, err) {
 			break
 		}
+		if rewindErr := r.rewindBody(offsets); rewindErr != nil {
+			if resp != nil && resp.Body != nil {
+				_ = resp.Body.Close()
+			}
+			err = fmt.Errorf("rewind request body: %w", rewindErr)
+			r.Client.runErrorHooks(r, err)
+			return nil, err
+		}
+		r.Client.runRetryHooks(policy, attempt, resp, err)
+		if resp != nil && resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+		if sleepErr := sleepContext(r.Context(), policy.wait(attempt, resp)); sleepErr != nil {
+			r.Client.runErrorHooks(r, sleepErr)
+			return resp, sleepErr
+		}
 	}
 
+	if err == nil {
+		err = r.decodeResult(resp)
+	}
+	if err != nil {
+		r.Client.runErrorHooks(r, err)
+	}
 	return resp, err
 }
 
+// decodeResult unmarshals the body into Result for 2xx responses and into
+// Error for 4xx and 5xx ones.
+func (r *Request) decodeResult(resp *Response) error {
+	if resp == nil || resp.Body != nil {
+		return nil
+	}
+	var v any
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		v = r.Result
+	case resp.StatusCode >= 400:
+		v = r.Error
+	}
+	if v == nil || len(resp.Bytes) == 0 {
+		return nil
+	}
+	if err := resp.Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func (r *Request) retryPolicy() *RetryPolicy {
+	if r.RetryPolicy != nil {
+		return r.RetryPolicy
+	}
+	return legacyRetryPolicy
+}
+
+func (r *Request) ExecuteContext(ctx context.Context, method, url string) (*Response, error) {
+	return r.SetContext(ctx).Execute(method, url)
+}
+
 func (r *Request) Get(url string) (*Response, error) {
 	return r.Execute(MethodGet, url)
 }
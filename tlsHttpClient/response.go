@@ -1,7 +1,7 @@
 package tlsHttpClient
 
 import (
-	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls"
+	"io"
 )
 
 type Response struct {
@@ -9,10 +9,21 @@ type Response struct {
 	Text       string
 	json       map[string]any
 	StatusCode int
-	Headers    map[string]string
-	Cookies    []cycletls.Cookie
+	Headers    Header
+	// Cookies is the jar's view of the request url after this response
+	Cookies []Cookie
+	// ResponseCookies are the cookies set by this response's Set-Cookie headers
+	ResponseCookies []Cookie
+	// Request is the request that produced this response
+	Request *Request
+	// Attempts is the number of attempts it took to get this response
+	Attempts int
+	// Body is only set when the request was sent with SetDoNotParseResponse
+	Body io.ReadCloser
 }
 
+// Json returns the body as a JSON object, it is empty for anything else such
+// as a top-level array, use Decode for those.
 func (r *Response) Json() map[string]any {
 	if r.json == nil {
 		r.json = map[string]any{}
@@ -20,6 +31,24 @@ func (r *Response) Json() map[string]any {
 	}
 	return r.json
 }
+
+// Decode unmarshals the body into v with the codec registered for the
+// response Content-Type, JSON when there is none.
+func (r *Response) Decode(v any) error {
+	codec := JSONCodec
+	if r.Request != nil {
+		codec = r.Request.Client.codec(r.Headers.Get("Content-Type"))
+	}
+	return codec.Unmarshal(r.Bytes, v)
+}
+
+// Decode unmarshals the body of resp into a new T, see Response.Decode.
+func Decode[T any](resp *Response) (T, error) {
+	var v T
+	err := resp.Decode(&v)
+	return v, err
+}
+
 func (r *Response) ToStruct(v interface{}) (interface{}, error) {
 	err := Unmarshal(r.Bytes, &v)
 	return v, err
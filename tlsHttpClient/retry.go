@@ -0,0 +1,210 @@
+package tlsHttpClient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"time"
+
+	http "github.com/Danny-Dasilva/fhttp"
+)
+
+// RetryConditionFunc reports whether an attempt should be retried, err is
+// the error returned by the attempt and resp may be nil.
+type RetryConditionFunc func(resp *Response, err error) bool
+
+// RetryHookFunc is called before every retry with the number of the attempt
+// that just failed.
+type RetryHookFunc func(attempt int, resp *Response, err error)
+
+// RetryPolicy decides which attempts of a request are retried and how long
+// to wait in between. The number of attempts is still set by Attempts.
+type RetryPolicy struct {
+	// WaitTime is the backoff before the first retry, doubled on every retry
+	WaitTime time.Duration
+	// MaxWaitTime caps the backoff and Retry-After, zero leaves Retry-After
+	// alone and caps the backoff at 2s or WaitTime when it is longer
+	MaxWaitTime time.Duration
+	// Jitter randomizes every wait between half and all of the backoff
+	Jitter bool
+	// StatusCodes are response status codes that are retried
+	StatusCodes []int
+	// RetryNonIdempotent allows retrying POST and PATCH requests
+	RetryNonIdempotent bool
+	// Conditions retry an attempt when any of them returns true, they also
+	// see the errors that are not retried on their own
+	Conditions []RetryConditionFunc
+	// Hooks are called before every retry
+	Hooks []RetryHookFunc
+}
+
+// legacyRetryPolicy retries every failed attempt immediately, it is used when
+// neither the client nor the request has a policy.
+var legacyRetryPolicy = &RetryPolicy{RetryNonIdempotent: true}
+
+// NewRetryPolicy returns a policy with jittered exponential backoff that
+// retries idempotent requests on transport errors and on 429, 502, 503 and 504.
+func NewRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		WaitTime:    defaultRetryWaitTime,
+		MaxWaitTime: defaultRetryMaxWaitTime,
+		Jitter:      true,
+		StatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+func (p *RetryPolicy) SetWaitTime(waitTime, maxWaitTime time.Duration) *RetryPolicy {
+	p.WaitTime = waitTime
+	p.MaxWaitTime = maxWaitTime
+	return p
+}
+
+func (p *RetryPolicy) SetStatusCodes(codes ...int) *RetryPolicy {
+	p.StatusCodes = codes
+	return p
+}
+
+func (p *RetryPolicy) SetRetryNonIdempotent(value bool) *RetryPolicy {
+	p.RetryNonIdempotent = value
+	return p
+}
+
+func (p *RetryPolicy) AddCondition(condition RetryConditionFunc) *RetryPolicy {
+	p.Conditions = append(p.Conditions, condition)
+	return p
+}
+
+func (p *RetryPolicy) AddHook(hook RetryHookFunc) *RetryPolicy {
+	p.Hooks = append(p.Hooks, hook)
+	return p
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case MethodGet, MethodHead, MethodOptions, MethodPut, MethodDelete, "TRACE", "":
+		return true
+	}
+	return false
+}
+
+func (p *RetryPolicy) shouldRetry(r *Request, resp *Response, err error) bool {
+	if !p.RetryNonIdempotent && !isIdempotent(r.Method) {
+		return false
+	}
+	if r.Context().Err() != nil {
+		return false
+	}
+	if err != nil && isTransportError(err) {
+		return true
+	}
+	if resp != nil {
+		for _, code := range p.StatusCodes {
+			if resp.StatusCode == code {
+				return true
+			}
+		}
+	}
+	for _, condition := range p.Conditions {
+		if condition(resp, err) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTransportError reports whether err is a failure to reach the server or
+// to get its response, as opposed to a request that can never succeed such
+// as a malformed URL or a rejected JA3, or a canceled context.
+func isTransportError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, ErrTimeout) || errors.Is(err, ErrDNS) ||
+		errors.Is(err, ErrProxyConnect) || errors.Is(err, ErrTLSHandshake) {
+		return true
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// wait returns the delay before the retry following attempt, a Retry-After
+// header sent with the response takes precedence over the backoff.
+func (p *RetryPolicy) wait(attempt int, resp *Response) time.Duration {
+	if resp != nil {
+		if delay, ok := parseRetryAfter(resp.Headers.Get("Retry-After")); ok {
+			if p.MaxWaitTime > 0 && delay > p.MaxWaitTime {
+				delay = p.MaxWaitTime
+			}
+			return delay
+		}
+	}
+	if p.WaitTime <= 0 {
+		return 0
+	}
+	maxWait := p.MaxWaitTime
+	if maxWait <= 0 {
+		maxWait = defaultRetryMaxWaitTime
+		if p.WaitTime > maxWait {
+			maxWait = p.WaitTime
+		}
+	}
+	delay := p.WaitTime
+	for i := 1; i < attempt && delay < maxWait; i++ {
+		delay *= 2
+	}
+	if delay > maxWait {
+		delay = maxWait
+	}
+	if p.Jitter {
+		half := delay / 2
+		delay = half + time.Duration(rand.Int63n(int64(half)+1))
+	}
+	return delay
+}
+
+// parseRetryAfter accepts both the delay-seconds and the HTTP-date form.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	delay := time.Until(date)
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,225 @@
+package tlsHttpClient
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/quotpw/tlsHttpClient/tlsHttpClient/cycletls"
+	"golang.org/x/crypto/scrypt"
+)
+
+const sessionVersion = 1
+
+// session is the saved form of everything that makes up a Client's browser
+// identity. Hooks, codecs and the retry policy hold functions and are not
+// saved.
+type session struct {
+	Version         int               `json:"version"`
+	BaseURL         string            `json:"baseUrl,omitempty"`
+	Ja3             string            `json:"ja3"`
+	Ja4             string            `json:"ja4,omitempty"`
+	Browser         string            `json:"browser,omitempty"`
+	HTTP2Settings   string            `json:"http2Settings,omitempty"`
+	Headers         Header            `json:"headers"`
+	HeaderOrder     []string          `json:"headerOrder,omitempty"`
+	PHeaderOrder    []string          `json:"pHeaderOrder,omitempty"`
+	QueryParam      Params            `json:"queryParams,omitempty"`
+	PathParams      map[string]string `json:"pathParams,omitempty"`
+	Proxy           *Proxy            `json:"proxy,omitempty"`
+	DisableRedirect bool              `json:"disableRedirect"`
+	Timeout         int               `json:"timeout"`
+	Attempts        int               `json:"attempts"`
+	Cookies         []Cookie          `json:"cookies"`
+}
+
+// encryptedSession is the envelope SaveEncryptedSession writes, the key is
+// derived from the passphrase with scrypt and the session sealed with
+// AES-256-GCM.
+type encryptedSession struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// scrypt parameters recommended for interactive use
+const (
+	sessionScryptN = 1 << 15
+	sessionScryptR = 8
+	sessionScryptP = 1
+)
+
+// SaveSession writes the client's identity to w as JSON: cookies, default
+// headers and their order, JA3, JA4, browser family and HTTP/2 settings,
+// user agent, proxy, base url, query and path parameters, and redirect,
+// timeout and attempt settings. The output holds cookies and proxy
+// credentials in the clear, see SaveEncryptedSession.
+func (c *Client) SaveSession(w io.Writer) error {
+	data, err := c.marshalSession()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// SaveEncryptedSession is SaveSession with the output encrypted with a key
+// derived from passphrase, it is read back with LoadEncryptedSession.
+func (c *Client) SaveEncryptedSession(w io.Writer, passphrase string) error {
+	data, err := c.marshalSession()
+	if err != nil {
+		return err
+	}
+
+	envelope := encryptedSession{
+		Version: sessionVersion,
+		KDF:     "scrypt",
+		Salt:    make([]byte, 16),
+	}
+	if _, err := rand.Read(envelope.Salt); err != nil {
+		return err
+	}
+	aead, err := sessionCipher(passphrase, envelope.Salt)
+	if err != nil {
+		return err
+	}
+	envelope.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(envelope.Nonce); err != nil {
+		return err
+	}
+	envelope.Ciphertext = aead.Seal(nil, envelope.Nonce, data, nil)
+	return json.NewEncoder(w).Encode(envelope)
+}
+
+func (c *Client) marshalSession() ([]byte, error) {
+	s := session{
+		Version:         sessionVersion,
+		BaseURL:         c.BaseURL,
+		Ja3:             c.Ja3,
+		Ja4:             c.Ja4,
+		Browser:         c.Browser,
+		Headers:         c.Props.Headers,
+		HeaderOrder:     c.Props.HeaderOrder,
+		PHeaderOrder:    c.Props.PHeaderOrder,
+		QueryParam:      c.Props.QueryParam,
+		PathParams:      c.Props.PathParams,
+		Proxy:           c.proxy,
+		DisableRedirect: c.Props.DisableRedirect,
+		Timeout:         c.Timeout,
+		Attempts:        c.Attempts,
+		Cookies:         c.CycleTLS.CookieJar.All(),
+	}
+	if c.HTTP2Settings != nil {
+		s.HTTP2Settings = c.HTTP2Settings.String()
+	}
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// LoadSession returns a new Client restored from a session written by
+// SaveSession. The user agent is part of the saved headers.
+func LoadSession(r io.Reader) (*Client, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var envelope encryptedSession
+	if json.Unmarshal(data, &envelope) == nil && envelope.Ciphertext != nil {
+		return nil, errors.New("session: session is encrypted, use LoadEncryptedSession")
+	}
+	return unmarshalSession(data)
+}
+
+// LoadEncryptedSession returns a new Client restored from a session written
+// by SaveEncryptedSession with the same passphrase.
+func LoadEncryptedSession(r io.Reader, passphrase string) (*Client, error) {
+	var envelope encryptedSession
+	if err := json.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Version != sessionVersion || envelope.KDF != "scrypt" {
+		return nil, fmt.Errorf("session: unsupported encrypted session version %d (%s)", envelope.Version, envelope.KDF)
+	}
+	aead, err := sessionCipher(passphrase, envelope.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, errors.New("session: invalid nonce")
+	}
+	data, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("session: wrong passphrase or corrupted session")
+	}
+	return unmarshalSession(data)
+}
+
+func unmarshalSession(data []byte) (*Client, error) {
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if s.Version != sessionVersion {
+		return nil, fmt.Errorf("session: unsupported session version %d", s.Version)
+	}
+
+	c := New()
+	c.BaseURL = s.BaseURL
+	if c.SetJA3(s.Ja3).err != nil {
+		return nil, fmt.Errorf("session: %w", c.err)
+	}
+	if s.Ja4 != "" {
+		if err := c.SetJA4(s.Ja4); err != nil {
+			return nil, fmt.Errorf("session: %w", err)
+		}
+	}
+	c.Browser = s.Browser
+	if err := c.SetHTTP2Settings(s.HTTP2Settings); err != nil {
+		return nil, fmt.Errorf("session: %w", err)
+	}
+	c.Timeout = s.Timeout
+	c.Attempts = s.Attempts
+	c.Props.DisableRedirect = s.DisableRedirect
+	c.Props.HeaderOrder = s.HeaderOrder
+	c.Props.PHeaderOrder = s.PHeaderOrder
+	if s.Headers != nil {
+		c.Props.Headers = s.Headers
+	}
+	if s.QueryParam != nil {
+		c.Props.QueryParam = s.QueryParam
+	}
+	if s.PathParams != nil {
+		c.Props.PathParams = s.PathParams
+	}
+	if s.Proxy != nil {
+		if err := c.SetProxy(s.Proxy); err != nil {
+			return nil, fmt.Errorf("session: %w", err)
+		}
+	}
+	jar := cycletls.NewJar()
+	if err := jar.Add(s.Cookies...); err != nil {
+		return nil, fmt.Errorf("session: %w", err)
+	}
+	c.SetCookieJar(jar)
+	return c, nil
+}
+
+func sessionCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, errors.New("session: passphrase is empty")
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, sessionScryptN, sessionScryptR, sessionScryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,365 @@
+package tlshttptest
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// TLS extension types the fingerprints look into.
+const (
+	extServerName          = 0x0000
+	extSupportedGroups     = 0x000a
+	extPointFormats        = 0x000b
+	extSignatureAlgorithms = 0x000d
+	extALPN                = 0x0010
+	extSupportedVersions   = 0x002b
+)
+
+// ClientHello is a parsed TLS ClientHello, lists keep the order they were
+// sent in and include GREASE values.
+type ClientHello struct {
+	// Version is the legacy version field of the ClientHello
+	Version             uint16   `json:"version"`
+	CipherSuites        []uint16 `json:"cipherSuites"`
+	Extensions          []uint16 `json:"extensions"`
+	ServerName          string   `json:"serverName,omitempty"`
+	SupportedGroups     []uint16 `json:"supportedGroups,omitempty"`
+	PointFormats        []uint8  `json:"pointFormats,omitempty"`
+	SignatureAlgorithms []uint16 `json:"signatureAlgorithms,omitempty"`
+	ALPN                []string `json:"alpn,omitempty"`
+	SupportedVersions   []uint16 `json:"supportedVersions,omitempty"`
+	// Raw is the handshake message, without the record layer
+	Raw []byte `json:"raw"`
+}
+
+// readClientHello reads the records carrying the ClientHello from r, it
+// returns the bytes read so they can be handed to the TLS server.
+func readClientHello(r io.Reader) (*ClientHello, []byte, error) {
+	var read, message []byte
+	for {
+		header := make([]byte, 5)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, read, err
+		}
+		read = append(read, header...)
+		if header[0] != 22 {
+			return nil, read, errors.New("tlshttptest: first record is not a handshake")
+		}
+		fragment := make([]byte, int(header[3])<<8|int(header[4]))
+		if _, err := io.ReadFull(r, fragment); err != nil {
+			return nil, read, err
+		}
+		read = append(read, fragment...)
+		message = append(message, fragment...)
+
+		if len(message) >= 4 {
+			length := int(message[1])<<16 | int(message[2])<<8 | int(message[3])
+			if len(message) >= 4+length {
+				hello, err := ParseClientHello(message[:4+length])
+				return hello, read, err
+			}
+		}
+	}
+}
+
+// ParseClientHello parses a ClientHello handshake message.
+func ParseClientHello(message []byte) (*ClientHello, error) {
+	hello := &ClientHello{Raw: append([]byte(nil), message...)}
+	s := cryptobyte.String(message)
+	var (
+		msgType      uint8
+		body, random cryptobyte.String
+		sessionID    cryptobyte.String
+		ciphers      cryptobyte.String
+		compression  cryptobyte.String
+	)
+	if !s.ReadUint8(&msgType) || msgType != 1 || !s.ReadUint24LengthPrefixed(&body) {
+		return nil, errors.New("tlshttptest: not a ClientHello")
+	}
+	if !body.ReadUint16(&hello.Version) || !body.ReadBytes((*[]byte)(&random), 32) ||
+		!body.ReadUint8LengthPrefixed(&sessionID) || !body.ReadUint16LengthPrefixed(&ciphers) ||
+		!body.ReadUint8LengthPrefixed(&compression) {
+		return nil, errors.New("tlshttptest: malformed ClientHello")
+	}
+	for !ciphers.Empty() {
+		var suite uint16
+		if !ciphers.ReadUint16(&suite) {
+			return nil, errors.New("tlshttptest: malformed cipher suites")
+		}
+		hello.CipherSuites = append(hello.CipherSuites, suite)
+	}
+	if body.Empty() {
+		return hello, nil
+	}
+
+	var extensions cryptobyte.String
+	if !body.ReadUint16LengthPrefixed(&extensions) {
+		return nil, errors.New("tlshttptest: malformed extensions")
+	}
+	for !extensions.Empty() {
+		var (
+			extType uint16
+			data    cryptobyte.String
+		)
+		if !extensions.ReadUint16(&extType) || !extensions.ReadUint16LengthPrefixed(&data) {
+			return nil, errors.New("tlshttptest: malformed extensions")
+		}
+		hello.Extensions = append(hello.Extensions, extType)
+		if err := hello.parseExtension(extType, data); err != nil {
+			return nil, fmt.Errorf("tlshttptest: extension %d: %w", extType, err)
+		}
+	}
+	return hello, nil
+}
+
+func (hello *ClientHello) parseExtension(extType uint16, data cryptobyte.String) error {
+	errMalformed := errors.New("malformed")
+	switch extType {
+	case extServerName:
+		var list cryptobyte.String
+		if !data.ReadUint16LengthPrefixed(&list) {
+			return errMalformed
+		}
+		for !list.Empty() {
+			var (
+				nameType uint8
+				name     cryptobyte.String
+			)
+			if !list.ReadUint8(&nameType) || !list.ReadUint16LengthPrefixed(&name) {
+				return errMalformed
+			}
+			if nameType == 0 {
+				hello.ServerName = string(name)
+			}
+		}
+	case extSupportedGroups, extSignatureAlgorithms:
+		var list cryptobyte.String
+		if !data.ReadUint16LengthPrefixed(&list) {
+			return errMalformed
+		}
+		values, ok := readUint16s(list)
+		if !ok {
+			return errMalformed
+		}
+		if extType == extSupportedGroups {
+			hello.SupportedGroups = values
+		} else {
+			hello.SignatureAlgorithms = values
+		}
+	case extPointFormats:
+		var list cryptobyte.String
+		if !data.ReadUint8LengthPrefixed(&list) {
+			return errMalformed
+		}
+		hello.PointFormats = append([]uint8(nil), list...)
+	case extALPN:
+		var list cryptobyte.String
+		if !data.ReadUint16LengthPrefixed(&list) {
+			return errMalformed
+		}
+		for !list.Empty() {
+			var proto cryptobyte.String
+			if !list.ReadUint8LengthPrefixed(&proto) {
+				return errMalformed
+			}
+			hello.ALPN = append(hello.ALPN, string(proto))
+		}
+	case extSupportedVersions:
+		var list cryptobyte.String
+		if !data.ReadUint8LengthPrefixed(&list) {
+			return errMalformed
+		}
+		values, ok := readUint16s(list)
+		if !ok {
+			return errMalformed
+		}
+		hello.SupportedVersions = values
+	}
+	return nil
+}
+
+func readUint16s(s cryptobyte.String) ([]uint16, bool) {
+	var values []uint16
+	for !s.Empty() {
+		var v uint16
+		if !s.ReadUint16(&v) {
+			return nil, false
+		}
+		values = append(values, v)
+	}
+	return values, true
+}
+
+// isGREASE reports whether v is one of the reserved GREASE values of RFC
+// 8701, which fingerprints ignore.
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+func withoutGREASE(values []uint16) []uint16 {
+	kept := make([]uint16, 0, len(values))
+	for _, v := range values {
+		if !isGREASE(v) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func joinDecimal(values []uint16) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(int(v))
+	}
+	return strings.Join(parts, "-")
+}
+
+// JA3 returns the JA3 string of the ClientHello.
+func (hello *ClientHello) JA3() string {
+	formats := make([]uint16, len(hello.PointFormats))
+	for i, f := range hello.PointFormats {
+		formats[i] = uint16(f)
+	}
+	return strings.Join([]string{
+		strconv.Itoa(int(hello.Version)),
+		joinDecimal(withoutGREASE(hello.CipherSuites)),
+		joinDecimal(withoutGREASE(hello.Extensions)),
+		joinDecimal(withoutGREASE(hello.SupportedGroups)),
+		joinDecimal(formats),
+	}, ",")
+}
+
+// JA3Hash returns the MD5 of the JA3 string in hex.
+func (hello *ClientHello) JA3Hash() string {
+	sum := md5.Sum([]byte(hello.JA3()))
+	return hex.EncodeToString(sum[:])
+}
+
+// JA4 returns the JA4 fingerprint of the ClientHello received over TCP.
+func (hello *ClientHello) JA4() string {
+	ciphers, extensions := hello.ja4Lists()
+	return hello.ja4Prefix() + "_" + ja4Hash(ciphers) + "_" + ja4Hash(extensions)
+}
+
+// JA4R returns the raw JA4 fingerprint, with the sorted lists in place of
+// their hashes.
+func (hello *ClientHello) JA4R() string {
+	ciphers, extensions := hello.ja4Lists()
+	return hello.ja4Prefix() + "_" + ciphers + "_" + extensions
+}
+
+func (hello *ClientHello) ja4Prefix() string {
+	version := hello.Version
+	if versions := withoutGREASE(hello.SupportedVersions); len(versions) > 0 {
+		version = 0
+		for _, v := range versions {
+			if v > version {
+				version = v
+			}
+		}
+	}
+	sni := "i"
+	if hello.ServerName != "" {
+		sni = "d"
+	}
+	return fmt.Sprintf("t%s%s%02d%02d%s", ja4Version(version), sni,
+		min99(len(withoutGREASE(hello.CipherSuites))), min99(len(withoutGREASE(hello.Extensions))), ja4ALPN(hello.ALPN))
+}
+
+// ja4Lists returns the sorted cipher suites and the sorted extensions
+// without SNI and ALPN followed by the signature algorithms, in hex.
+func (hello *ClientHello) ja4Lists() (string, string) {
+	var extensions []uint16
+	for _, ext := range withoutGREASE(hello.Extensions) {
+		if ext != extServerName && ext != extALPN {
+			extensions = append(extensions, ext)
+		}
+	}
+	list := sortedHex(extensions)
+	if algorithms := withoutGREASE(hello.SignatureAlgorithms); len(algorithms) > 0 {
+		list += "_" + joinHex(algorithms)
+	}
+	return sortedHex(withoutGREASE(hello.CipherSuites)), list
+}
+
+func ja4Version(version uint16) string {
+	switch version {
+	case 0x0304:
+		return "13"
+	case 0x0303:
+		return "12"
+	case 0x0302:
+		return "11"
+	case 0x0301:
+		return "10"
+	case 0x0300:
+		return "s3"
+	case 0x0002:
+		return "s2"
+	case 0xfeff:
+		return "d1"
+	case 0xfefd:
+		return "d2"
+	case 0xfefc:
+		return "d3"
+	}
+	return "00"
+}
+
+// ja4ALPN returns the first and last characters of the first ALPN value,
+// or of its hex form when they are not alphanumeric.
+func ja4ALPN(alpn []string) string {
+	if len(alpn) == 0 || alpn[0] == "" {
+		return "00"
+	}
+	value := alpn[0]
+	first, last := value[0], value[len(value)-1]
+	if isAlphanumeric(first) && isAlphanumeric(last) {
+		return string([]byte{first, last})
+	}
+	h := hex.EncodeToString([]byte(value))
+	return string([]byte{h[0], h[len(h)-1]})
+}
+
+func isAlphanumeric(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func min99(n int) int {
+	if n > 99 {
+		return 99
+	}
+	return n
+}
+
+func joinHex(values []uint16) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%04x", v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func sortedHex(values []uint16) string {
+	sorted := append([]uint16(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return joinHex(sorted)
+}
+
+// ja4Hash returns the first 12 hex characters of the SHA-256 of list.
+func ja4Hash(list string) string {
+	if list == "" {
+		return "000000000000"
+	}
+	sum := sha256.Sum256([]byte(list))
+	return hex.EncodeToString(sum[:])[:12]
+}
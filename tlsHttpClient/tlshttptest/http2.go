@@ -0,0 +1,151 @@
+package tlshttptest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// HTTP2Setting is one parameter of the client's first SETTINGS frame.
+type HTTP2Setting struct {
+	ID    uint16 `json:"id"`
+	Value uint32 `json:"value"`
+}
+
+// HTTP2Priority is a PRIORITY frame, Weight is the effective weight from 1
+// to 256, one more than the value on the wire.
+type HTTP2Priority struct {
+	StreamID  uint32 `json:"streamId"`
+	Exclusive bool   `json:"exclusive"`
+	DependsOn uint32 `json:"dependsOn"`
+	Weight    uint16 `json:"weight"`
+}
+
+// HTTP2Fingerprint is what the client sent on an HTTP/2 connection before
+// its first request, in the terms of Akamai's passive fingerprinting.
+type HTTP2Fingerprint struct {
+	Settings []HTTP2Setting `json:"settings"`
+	// WindowUpdate is the increment of the connection WINDOW_UPDATE, 0 when
+	// none was sent
+	WindowUpdate      uint32          `json:"windowUpdate"`
+	Priorities        []HTTP2Priority `json:"priorities,omitempty"`
+	PseudoHeaderOrder []string        `json:"pseudoHeaderOrder"`
+	// Akamai is SETTINGS|WINDOW_UPDATE|PRIORITY|PSEUDO_HEADER_ORDER, as in
+	// 1:65536;3:1000;4:6291456|15663105|0|m,a,s,p
+	Akamai     string `json:"akamai"`
+	AkamaiHash string `json:"akamaiHash"`
+}
+
+// requestHead is the header block of one request as it was received.
+type requestHead struct {
+	method      string
+	path        string
+	headerOrder []string
+	pseudoOrder []string
+}
+
+// parseHTTP2 reads the frames of data, the decrypted bytes a client sent on
+// an HTTP/2 connection. It stops at the first incomplete frame.
+func parseHTTP2(data []byte) (*HTTP2Fingerprint, []requestHead) {
+	fingerprint := &HTTP2Fingerprint{}
+	if !bytes.HasPrefix(data, []byte(http2.ClientPreface)) {
+		return fingerprint, nil
+	}
+	framer := http2.NewFramer(nil, bytes.NewReader(data[len(http2.ClientPreface):]))
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+
+	var (
+		heads       []requestHead
+		gotSettings bool
+	)
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			break
+		}
+		switch f := frame.(type) {
+		case *http2.SettingsFrame:
+			if f.IsAck() || gotSettings || len(heads) > 0 {
+				continue
+			}
+			gotSettings = true
+			_ = f.ForeachSetting(func(setting http2.Setting) error {
+				fingerprint.Settings = append(fingerprint.Settings, HTTP2Setting{ID: uint16(setting.ID), Value: setting.Val})
+				return nil
+			})
+		case *http2.WindowUpdateFrame:
+			if f.StreamID == 0 && fingerprint.WindowUpdate == 0 && len(heads) == 0 {
+				fingerprint.WindowUpdate = f.Increment
+			}
+		case *http2.PriorityFrame:
+			if len(heads) == 0 {
+				fingerprint.Priorities = append(fingerprint.Priorities, HTTP2Priority{
+					StreamID:  f.StreamID,
+					Exclusive: f.Exclusive,
+					DependsOn: f.StreamDep,
+					Weight:    uint16(f.Weight) + 1,
+				})
+			}
+		case *http2.MetaHeadersFrame:
+			var head requestHead
+			for _, field := range f.Fields {
+				if !field.IsPseudo() {
+					head.headerOrder = append(head.headerOrder, field.Name)
+					continue
+				}
+				head.pseudoOrder = append(head.pseudoOrder, field.Name)
+				switch field.Name {
+				case ":method":
+					head.method = field.Value
+				case ":path":
+					head.path = field.Value
+				}
+			}
+			if len(heads) == 0 {
+				fingerprint.PseudoHeaderOrder = head.pseudoOrder
+			}
+			heads = append(heads, head)
+		}
+	}
+	fingerprint.Akamai = fingerprint.akamai()
+	sum := md5.Sum([]byte(fingerprint.Akamai))
+	fingerprint.AkamaiHash = hex.EncodeToString(sum[:])
+	return fingerprint, heads
+}
+
+func (f *HTTP2Fingerprint) akamai() string {
+	settings := make([]string, len(f.Settings))
+	for i, setting := range f.Settings {
+		settings[i] = fmt.Sprintf("%d:%d", setting.ID, setting.Value)
+	}
+
+	windowUpdate := "00"
+	if f.WindowUpdate != 0 {
+		windowUpdate = strconv.FormatUint(uint64(f.WindowUpdate), 10)
+	}
+
+	priorities := "0"
+	if len(f.Priorities) > 0 {
+		parts := make([]string, len(f.Priorities))
+		for i, p := range f.Priorities {
+			exclusive := 0
+			if p.Exclusive {
+				exclusive = 1
+			}
+			parts[i] = fmt.Sprintf("%d:%d:%d:%d", p.StreamID, exclusive, p.DependsOn, p.Weight)
+		}
+		priorities = strings.Join(parts, ",")
+	}
+
+	pseudo := make([]string, len(f.PseudoHeaderOrder))
+	for i, name := range f.PseudoHeaderOrder {
+		pseudo[i] = name[1:2]
+	}
+	return strings.Join([]string{strings.Join(settings, ";"), windowUpdate, priorities, strings.Join(pseudo, ",")}, "|")
+}
@@ -0,0 +1,408 @@
+// Package tlshttptest provides a loopback TLS server that reports the
+// fingerprint of the clients that reach it: the ClientHello with its JA3 and
+// JA4, the HTTP/2 connection preface in Akamai's format and the order of the
+// request headers. It lets tests check the fingerprint a client really puts
+// on the wire without an external echo service.
+package tlshttptest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// Fingerprint is what the server saw of one request. Every request is
+// answered with its Fingerprint as JSON.
+type Fingerprint struct {
+	Method      string       `json:"method"`
+	Path        string       `json:"path"`
+	Proto       string       `json:"proto"`
+	ClientHello *ClientHello `json:"clientHello"`
+	JA3         string       `json:"ja3"`
+	JA3Hash     string       `json:"ja3Hash"`
+	JA4         string       `json:"ja4"`
+	JA4R        string       `json:"ja4r"`
+	// HTTP2 is nil for HTTP/1 requests
+	HTTP2 *HTTP2Fingerprint `json:"http2,omitempty"`
+	// HeaderOrder holds the names of the request headers as received,
+	// without the HTTP/2 pseudo headers
+	HeaderOrder []string `json:"headerOrder"`
+}
+
+// Server is a TLS server on loopback that negotiates h2 or http/1.1 and
+// records the Fingerprint of every request.
+type Server struct {
+	// URL is the base url of the server, https://127.0.0.1:port
+	URL string
+	// Certificate is the self-signed certificate of the server
+	Certificate *x509.Certificate
+
+	listener  net.Listener
+	tlsConfig *tls.Config
+	h1        *http.Server
+	h1conns   *connListener
+	h2        *http2.Server
+
+	mu           sync.Mutex
+	fingerprints []Fingerprint
+	conns        map[net.Conn]struct{}
+	closed       bool
+}
+
+type captureKey struct{}
+
+// NewServer starts a Server, it must be closed with Close.
+func NewServer() *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("tlshttptest: failed to listen: " + err.Error())
+	}
+	certificate, err := newCertificate()
+	if err != nil {
+		panic("tlshttptest: failed to create certificate: " + err.Error())
+	}
+
+	s := &Server{
+		URL:         "https://" + listener.Addr().String(),
+		Certificate: certificate.Leaf,
+		listener:    listener,
+		tlsConfig: &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			NextProtos:   []string{http2.NextProtoTLS, "http/1.1"},
+		},
+		h1conns: &connListener{addr: listener.Addr(), conns: make(chan net.Conn), done: make(chan struct{})},
+		h2:      &http2.Server{PermitProhibitedCipherSuites: true},
+		conns:   make(map[net.Conn]struct{}),
+	}
+	s.h1 = &http.Server{
+		Handler: s,
+		ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+			return context.WithValue(ctx, captureKey{}, c)
+		},
+	}
+	go func() { _ = s.h1.Serve(s.h1conns) }()
+	go s.serve()
+	return s
+}
+
+// Fingerprints returns the fingerprints of the requests received so far.
+func (s *Server) Fingerprints() []Fingerprint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Fingerprint(nil), s.fingerprints...)
+}
+
+// Last returns the fingerprint of the last request, false when there was
+// none.
+func (s *Server) Last() (Fingerprint, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.fingerprints) == 0 {
+		return Fingerprint{}, false
+	}
+	return s.fingerprints[len(s.fingerprints)-1], true
+}
+
+// Close stops the server and closes its connections.
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+
+	_ = s.listener.Close()
+	_ = s.h1.Close()
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+// handle reads the ClientHello of conn, completes the handshake and passes
+// the connection to the server of the negotiated protocol.
+func (s *Server) handle(raw net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, raw)
+		s.mu.Unlock()
+	}()
+
+	_ = raw.SetDeadline(time.Now().Add(10 * time.Second))
+	hello, read, err := readClientHello(raw)
+	if err != nil {
+		_ = raw.Close()
+		return
+	}
+	tlsConn := tls.Server(&replayConn{Conn: raw, pending: read}, s.tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		_ = raw.Close()
+		return
+	}
+	_ = raw.SetDeadline(time.Time{})
+
+	conn := &captureConn{Conn: tlsConn, tls: tlsConn, hello: hello, claimed: make(map[int]bool)}
+	if tlsConn.ConnectionState().NegotiatedProtocol == http2.NextProtoTLS {
+		conn.http2 = true
+		s.h2.ServeConn(conn, &http2.ServeConnOpts{
+			Context:    context.WithValue(context.Background(), captureKey{}, conn),
+			BaseConfig: s.h1,
+			Handler:    s,
+		})
+		return
+	}
+	select {
+	case s.h1conns.conns <- conn:
+	case <-s.h1conns.done:
+		_ = conn.Close()
+	}
+}
+
+// ServeHTTP records the fingerprint of r and writes it back as JSON.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_, _ = io.Copy(io.Discard, r.Body)
+
+	fingerprint := Fingerprint{Method: r.Method, Path: r.RequestURI, Proto: r.Proto}
+	if conn, ok := r.Context().Value(captureKey{}).(*captureConn); ok {
+		fingerprint.ClientHello = conn.hello
+		fingerprint.JA3 = conn.hello.JA3()
+		fingerprint.JA3Hash = conn.hello.JA3Hash()
+		fingerprint.JA4 = conn.hello.JA4()
+		fingerprint.JA4R = conn.hello.JA4R()
+		fingerprint.HTTP2, fingerprint.HeaderOrder = conn.claim(r.Method, r.RequestURI)
+	}
+
+	s.mu.Lock()
+	s.fingerprints = append(s.fingerprints, fingerprint)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(fingerprint)
+}
+
+// captureConn keeps a copy of everything the client sent after the
+// handshake.
+type captureConn struct {
+	net.Conn
+	tls   *tls.Conn
+	hello *ClientHello
+	http2 bool
+
+	mu       sync.Mutex
+	received bytes.Buffer
+	claimed  map[int]bool
+}
+
+func (c *captureConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.mu.Lock()
+	c.received.Write(p[:n])
+	c.mu.Unlock()
+	return n, err
+}
+
+// ConnectionState lets the servers see the TLS state through the wrapper.
+func (c *captureConn) ConnectionState() tls.ConnectionState {
+	return c.tls.ConnectionState()
+}
+
+// claim returns the header order of the first request with method and path
+// that has not been claimed yet, and the HTTP/2 fingerprint of the
+// connection.
+func (c *captureConn) claim(method, path string) (*HTTP2Fingerprint, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var (
+		fingerprint *HTTP2Fingerprint
+		heads       []requestHead
+	)
+	if c.http2 {
+		fingerprint, heads = parseHTTP2(c.received.Bytes())
+	} else {
+		heads = parseHTTP1(c.received.Bytes())
+	}
+	for i, head := range heads {
+		if !c.claimed[i] && head.method == method && head.path == path {
+			c.claimed[i] = true
+			return fingerprint, head.headerOrder
+		}
+	}
+	return fingerprint, nil
+}
+
+// parseHTTP1 reads the request heads of data, the decrypted bytes a client
+// sent on an HTTP/1 connection, skipping the bodies. It stops at the first
+// incomplete request.
+func parseHTTP1(data []byte) []requestHead {
+	var heads []requestHead
+	r := bufio.NewReader(bytes.NewReader(data))
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return heads
+		}
+		parts := strings.Fields(line)
+		if len(parts) != 3 {
+			return heads
+		}
+		head := requestHead{method: parts[0], path: parts[1]}
+
+		var (
+			contentLength int64
+			chunked       bool
+		)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return heads
+			}
+			line = strings.TrimRight(line, "\r\n")
+			if line == "" {
+				break
+			}
+			name, value, _ := strings.Cut(line, ":")
+			value = strings.TrimSpace(value)
+			head.headerOrder = append(head.headerOrder, name)
+			switch strings.ToLower(name) {
+			case "content-length":
+				contentLength, _ = strconv.ParseInt(value, 10, 64)
+			case "transfer-encoding":
+				chunked = strings.EqualFold(value, "chunked")
+			}
+		}
+		heads = append(heads, head)
+
+		body := io.LimitReader(r, contentLength)
+		if chunked {
+			body = httputil.NewChunkedReader(r)
+		}
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			return heads
+		}
+		if chunked {
+			// trailers end with an empty line
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return heads
+				}
+				if strings.TrimRight(line, "\r\n") == "" {
+					break
+				}
+			}
+		}
+	}
+}
+
+// replayConn returns the bytes read while parsing the ClientHello before
+// reading from the connection again.
+type replayConn struct {
+	net.Conn
+	pending []byte
+}
+
+func (c *replayConn) Read(p []byte) (int, error) {
+	if len(c.pending) > 0 {
+		n := copy(p, c.pending)
+		c.pending = c.pending[n:]
+		return n, nil
+	}
+	return c.Conn.Read(p)
+}
+
+// connListener hands the HTTP/1 connections to http.Server.
+type connListener struct {
+	addr  net.Addr
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *connListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
+
+// newCertificate returns a self-signed certificate for 127.0.0.1, ::1 and
+// localhost.
+func newCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"tlshttptest"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		DNSNames:              []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
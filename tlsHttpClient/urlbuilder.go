@@ -0,0 +1,152 @@
+package tlsHttpClient
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// buildURL resolves rawURL against baseURL, fills in {name} path parameters,
+// converts an IDN host to punycode and merges query, whose values replace
+// parameters of the same name already in the URL. Parameter order, the
+// encoding of the query already in the URL and the fragment are kept.
+func buildURL(baseURL, rawURL string, pathParams map[string]string, query Params) (string, error) {
+	rawURL = expandPathParams(rawURL, pathParams)
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if baseURL != "" && !u.IsAbs() {
+		if u, err = joinBaseURL(baseURL, u); err != nil {
+			return "", err
+		}
+		rawURL = u.String()
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("url %q is not absolute, set a base url with SetBaseURL", rawURL)
+	}
+
+	if hostname := u.Hostname(); !isASCII(hostname) {
+		host, err := idna.Lookup.ToASCII(hostname)
+		if err != nil {
+			return "", fmt.Errorf("invalid host %q: %w", hostname, err)
+		}
+		if port := u.Port(); port != "" {
+			host = net.JoinHostPort(host, port)
+		}
+		u.Host = host
+	}
+
+	if len(query) > 0 {
+		u.RawQuery = mergeRawQuery(u.RawQuery, query)
+		u.ForceQuery = false
+	}
+	return u.String(), nil
+}
+
+// joinBaseURL appends the path of ref to the path of baseURL. The query of
+// the base comes first, followed by the one of ref, the fragment is ref's.
+func joinBaseURL(baseURL string, ref *url.URL) (*url.URL, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Path != "" {
+		joined := strings.TrimRight(u.EscapedPath(), "/") + "/" + strings.TrimLeft(ref.EscapedPath(), "/")
+		if u.Path, err = url.PathUnescape(joined); err != nil {
+			return nil, err
+		}
+		u.RawPath = joined
+	}
+	switch {
+	case u.RawQuery == "":
+		u.RawQuery = ref.RawQuery
+	case ref.RawQuery != "":
+		u.RawQuery += "&" + ref.RawQuery
+	}
+	u.ForceQuery = u.ForceQuery || ref.ForceQuery
+	u.Fragment, u.RawFragment = ref.Fragment, ref.RawFragment
+	return u, nil
+}
+
+// expandPathParams replaces the {name} placeholders of template in a single
+// pass, so values holding placeholders themselves are not expanded again.
+// Placeholders without a parameter are kept.
+func expandPathParams(template string, pathParams map[string]string) string {
+	if len(pathParams) == 0 {
+		return template
+	}
+	var buf strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		buf.WriteString(template[:start])
+		if value, ok := pathParams[template[start+1:end]]; ok {
+			buf.WriteString(url.PathEscape(value))
+		} else {
+			buf.WriteString(template[start : end+1])
+		}
+		template = template[end+1:]
+	}
+	buf.WriteString(template)
+	return buf.String()
+}
+
+// mergeRawQuery replaces the pairs of rawQuery whose key is in query with
+// the values of query, at the position of the key's first pair, and appends
+// the keys new to rawQuery. Pairs it does not replace are kept as they are,
+// valueless keys and percent-encoding included.
+func mergeRawQuery(rawQuery string, query Params) string {
+	var pairs []string
+	replaced := map[string]bool{}
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		rawKey, _, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil || !query.Has(key) {
+			pairs = append(pairs, pair)
+			continue
+		}
+		if !replaced[key] {
+			replaced[key] = true
+			pairs = append(pairs, encodeParam(key, query.Values(key))...)
+		}
+	}
+	for _, param := range query {
+		if !replaced[param.Key] {
+			replaced[param.Key] = true
+			pairs = append(pairs, encodeParam(param.Key, query.Values(param.Key))...)
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func encodeParam(key string, values []string) []string {
+	pairs := make([]string, len(values))
+	for i, value := range values {
+		pairs[i] = url.QueryEscape(key) + "=" + url.QueryEscape(value)
+	}
+	return pairs
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}